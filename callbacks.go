@@ -0,0 +1,183 @@
+package entigorm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	startedAtKey = "entigorm:started_at"
+	heldConnKey  = "entigorm:held_conn"
+)
+
+var (
+	slowQueryThreshold time.Duration
+	slowQueryHooks     []func(sql string, elapsed time.Duration)
+	queryLogger        Logger
+)
+
+// Logger receives every statement run through the package db, to plug in a
+// structured logger such as slog or zap.
+type Logger interface {
+	// LogQuery is called after the statement sql ran with args, err being nil
+	// unless it failed.
+	LogQuery(ctx context.Context, sql string, args []any, elapsed time.Duration, rowsAffected int64, err error)
+}
+
+// SetLogger makes logger receive every statement, a nil logger disables logging.
+func SetLogger(logger Logger) {
+	queryLogger = logger
+}
+
+// SetSlowQueryThreshold reports every statement running longer than d to the
+// OnSlowQuery hooks. A value <= 0 disables slow query reporting.
+func SetSlowQueryThreshold(d time.Duration) {
+	slowQueryThreshold = d
+}
+
+// OnSlowQuery registers fn to receive the interpolated SQL and duration of slow statements.
+func OnSlowQuery(fn func(sql string, elapsed time.Duration)) {
+	slowQueryHooks = append(slowQueryHooks, fn)
+}
+
+// registerCallbacks times every statement run through gormdb, runs it on a
+// connection of its own and groups the conditions added by OrWhere.
+func registerCallbacks(gormdb *gorm.DB) error {
+	callbacks := gormdb.Callback()
+
+	return errors.Join(
+		callbacks.Create().Before("*").Register("entigorm:hold_conn_create", holdConn),
+		callbacks.Create().After("*").Register("entigorm:release_conn_create", releaseConn(false)),
+		callbacks.Query().Before("*").Register("entigorm:hold_conn_query", holdConn),
+		callbacks.Query().After("*").Register("entigorm:release_conn_query", releaseConn(false)),
+		callbacks.Update().Before("*").Register("entigorm:hold_conn_update", holdConn),
+		callbacks.Update().After("*").Register("entigorm:release_conn_update", releaseConn(false)),
+		callbacks.Delete().Before("*").Register("entigorm:hold_conn_delete", holdConn),
+		callbacks.Delete().After("*").Register("entigorm:release_conn_delete", releaseConn(false)),
+		callbacks.Row().Before("*").Register("entigorm:hold_conn_row", holdConn),
+		callbacks.Row().After("*").Register("entigorm:release_conn_row", releaseConn(true)),
+		callbacks.Raw().Before("*").Register("entigorm:hold_conn_raw", holdConn),
+		callbacks.Raw().After("*").Register("entigorm:release_conn_raw", releaseConn(false)),
+		callbacks.Query().Before("gorm:query").Register("entigorm:or_where_query", groupOrConditions),
+		callbacks.Update().Before("gorm:update").Register("entigorm:or_where_update", groupOrConditions),
+		callbacks.Delete().Before("gorm:delete").Register("entigorm:or_where_delete", groupOrConditions),
+		callbacks.Row().Before("gorm:row").Register("entigorm:or_where_row", groupOrConditions),
+		callbacks.Create().Before("gorm:create").Register("entigorm:before_create", startTimer),
+		callbacks.Create().After("gorm:create").Register("entigorm:after_create", stopTimer("insert")),
+		callbacks.Query().Before("gorm:query").Register("entigorm:before_query", startTimer),
+		callbacks.Query().After("gorm:query").Register("entigorm:after_query", stopTimer("find")),
+		callbacks.Update().Before("gorm:update").Register("entigorm:before_update", startTimer),
+		callbacks.Update().After("gorm:update").Register("entigorm:after_update", stopTimer("update")),
+		callbacks.Delete().Before("gorm:delete").Register("entigorm:before_delete", startTimer),
+		callbacks.Delete().After("gorm:delete").Register("entigorm:after_delete", stopTimer("delete")),
+		callbacks.Row().Before("gorm:row").Register("entigorm:before_row", startTimer),
+		callbacks.Row().After("gorm:row").Register("entigorm:after_row", stopTimer("find")),
+		callbacks.Raw().Before("gorm:raw").Register("entigorm:before_raw", startTimer),
+		callbacks.Raw().After("gorm:raw").Register("entigorm:after_raw", stopTimer("raw")),
+	)
+}
+
+// heldConn is the connection holdConn took for a statement from pool.
+type heldConn struct {
+	pool gorm.ConnPool
+	conn *sql.Conn
+}
+
+// holdConn runs the statement on a connection taken by takeConn, so that only
+// its own wait for a connection is reported as ErrPoolExhausted, not that of
+// concurrent statements.
+func holdConn(tx *gorm.DB) {
+	if tx.Error != nil || tx.DryRun {
+		return
+	}
+
+	conn, err := takeConn(tx)
+	if err != nil {
+		_ = tx.AddError(err)
+
+		return
+	}
+
+	if conn == nil {
+		return
+	}
+
+	tx.InstanceSet(heldConnKey, heldConn{pool: tx.Statement.ConnPool, conn: conn})
+	tx.Statement.ConnPool = conn
+}
+
+// releaseConn gives the connection taken by holdConn back to its pool. With
+// async, as for Row and Rows whose rows outlive the statement, that happens once
+// the rows are closed.
+func releaseConn(async bool) func(*gorm.DB) {
+	return func(tx *gorm.DB) {
+		v, ok := tx.InstanceGet(heldConnKey)
+		if !ok {
+			return
+		}
+
+		held, ok := v.(heldConn)
+		if !ok {
+			return
+		}
+
+		tx.InstanceSet(heldConnKey, nil)
+		tx.Statement.ConnPool = held.pool
+
+		if async {
+			// Close waits for the rows to be closed before releasing the connection.
+			go held.conn.Close() //nolint:errcheck
+
+			return
+		}
+
+		held.conn.Close()
+	}
+}
+
+func startTimer(tx *gorm.DB) {
+	tx.InstanceSet(startedAtKey, time.Now())
+}
+
+// stopTimer reports the statement timed by startTimer, op naming its kind.
+// Statements only rendered through DryRun, as by ExplainSQL, are not reported.
+func stopTimer(op string) func(*gorm.DB) {
+	return func(tx *gorm.DB) {
+		if tx.DryRun {
+			return
+		}
+
+		v, ok := tx.InstanceGet(startedAtKey)
+		if !ok {
+			return
+		}
+
+		elapsed := time.Since(v.(time.Time))
+
+		if metrics != nil {
+			metrics.observe(op, tx.Statement.Table, elapsed, tx.Error)
+		}
+
+		if queryLogger != nil {
+			queryLogger.LogQuery(
+				tx.Statement.Context,
+				tx.Statement.SQL.String(),
+				tx.Statement.Vars,
+				elapsed,
+				tx.Statement.RowsAffected,
+				tx.Error,
+			)
+		}
+
+		if slowQueryThreshold > 0 && elapsed > slowQueryThreshold && len(slowQueryHooks) > 0 {
+			sql := tx.Dialector.Explain(tx.Statement.SQL.String(), tx.Statement.Vars...)
+			for _, hook := range slowQueryHooks {
+				hook(sql, elapsed)
+			}
+		}
+	}
+}
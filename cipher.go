@@ -0,0 +1,100 @@
+package entigorm
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+)
+
+// Cipher encrypts and decrypts the value of a column, see WithFieldCipher.
+type Cipher interface {
+	Encrypt(plaintext string) (string, error)
+	Decrypt(ciphertext string) (string, error)
+}
+
+// WithFieldCipher encrypts column with cipher before it is written and decrypts it
+// after it is read. Only string fields are supported.
+func (e *Entity[E]) WithFieldCipher(column string, cipher Cipher) Entitier[E] {
+	if e.ciphers == nil {
+		e.ciphers = make(map[string]Cipher)
+	}
+
+	e.ciphers[column] = cipher
+
+	return e
+}
+
+func (e *Entity[E]) encryptFields(ctx context.Context, values any) error {
+	return e.applyCiphers(ctx, values, Cipher.Encrypt)
+}
+
+func (e *Entity[E]) decryptFields(ctx context.Context, values any) error {
+	return e.applyCiphers(ctx, values, Cipher.Decrypt)
+}
+
+// applyCiphers transforms the ciphered columns of values, which is a struct, a
+// pointer to one, or a slice of either.
+func (e *Entity[E]) applyCiphers(
+	ctx context.Context,
+	values any,
+	transform func(Cipher, string) (string, error),
+) error {
+	if len(e.ciphers) == 0 {
+		return nil
+	}
+
+	if err := ready(); err != nil {
+		return err
+	}
+
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(e.table); err != nil {
+		return err
+	}
+
+	rv := reflect.ValueOf(values)
+	for rv.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+	}
+
+	rows := []reflect.Value{rv}
+
+	if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+		rows = make([]reflect.Value, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			rows = append(rows, reflect.Indirect(rv.Index(i)))
+		}
+	}
+
+	for column, cipher := range e.ciphers {
+		field := stmt.Schema.LookUpField(column)
+		if field == nil {
+			return fmt.Errorf("%w: %s", ErrInvalidField, column)
+		}
+
+		for _, row := range rows {
+			value, zero := field.ValueOf(ctx, row)
+			if zero {
+				continue
+			}
+
+			text, ok := value.(string)
+			if !ok {
+				return fmt.Errorf("%w: %s is not a string", ErrInvalidField, column)
+			}
+
+			text, err := transform(cipher, text)
+			if err != nil {
+				return err
+			}
+
+			if err := field.Set(ctx, row, text); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package entigorm
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+)
+
+// base64Cipher stands in for a real cipher, encoding values in base64.
+type base64Cipher struct{}
+
+func (base64Cipher) Encrypt(plaintext string) (string, error) {
+	return base64.StdEncoding.EncodeToString([]byte(plaintext)), nil
+}
+
+func (base64Cipher) Decrypt(ciphertext string) (string, error) {
+	plaintext, err := base64.StdEncoding.DecodeString(ciphertext)
+
+	return string(plaintext), err
+}
+
+func TestWithFieldCipher(t *testing.T) {
+	gormdb := openTestDB(t, &testUser{})
+
+	ctx := context.Background()
+
+	user := &testUser{Name: "ann"}
+	if err := SQL(user).WithFieldCipher("name", base64Cipher{}).Insert(ctx); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+
+	if user.Name != "ann" {
+		t.Fatalf("Insert left the entity encrypted: %q", user.Name)
+	}
+
+	var stored string
+	if err := gormdb.Raw("SELECT name FROM users WHERE id = ?", user.ID).Scan(&stored).Error; err != nil {
+		t.Fatalf("read the stored name: %v", err)
+	}
+
+	if want, _ := (base64Cipher{}).Encrypt("ann"); stored != want {
+		t.Fatalf("stored name %q, want %q", stored, want)
+	}
+
+	users, err := SQL(&testUser{}).WithFieldCipher("name", base64Cipher{}).Find(ctx)
+	if err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+
+	if len(users) != 1 || users[0].Name != "ann" {
+		t.Fatalf("Find = %+v, want the decrypted ann", users)
+	}
+}
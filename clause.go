@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+
+	"gorm.io/gorm"
 )
 
 type Builer struct {
@@ -11,13 +13,26 @@ type Builer struct {
 	value      any
 	operator   string
 	nextBoolOP string
+	// args are bound by fragments rendered verbatim from key, such as groups.
+	args []any
+	// dialect is the only database the entry can be rendered for, when set.
+	dialect string
 }
 
 type Clause struct {
 	builder []Builer
 	not     bool
+	// err records an invalid build, it is reported when the clause is applied.
+	err   error
+	binds map[string]any
 }
 
+// Param is a named placeholder usable as the value of any predicate, its value
+// is given once with Bind and fed to every predicate using it:
+//
+//	EQ("first_name", Param("term")).OR().EQ("last_name", Param("term")).Bind("term", "bob")
+type Param string
+
 func (w *Clause) EQ(field string, value any) *Clause {
 	if w.not {
 		field = NOTOperator + field
@@ -87,6 +102,34 @@ func (w *Clause) IN(field string, values any) *Clause {
 	return w
 }
 
+func (w *Clause) NotIn(field string, values []any) *Clause {
+	if w.not {
+		field = NOTOperator + field
+	}
+
+	w.builder = append(w.builder, NotIn(field, values).builder...)
+	w.not = false
+
+	return w
+}
+
+// TupleIn appends a TupleIn match, negated as NOT (a, b) IN ... after NOT().
+func (w *Clause) TupleIn(fields []string, rows [][]any) *Clause {
+	other := TupleIn(fields, rows)
+	if w.not && len(other.builder) > 0 {
+		other.builder[0].key = NOTOperator + other.builder[0].key
+	}
+
+	w.builder = append(w.builder, other.builder...)
+	w.not = false
+
+	if other.err != nil {
+		w.err = other.err
+	}
+
+	return w
+}
+
 func (w *Clause) Like(field, value string) *Clause {
 	if w.not {
 		field = NOTOperator + field
@@ -98,17 +141,156 @@ func (w *Clause) Like(field, value string) *Clause {
 	return w
 }
 
-func (w *Clause) Between(field string, value any) *Clause {
+func (w *Clause) ILike(field, value string) *Clause {
+	if w.not {
+		field = NOTOperator + field
+	}
+
+	w.builder = append(w.builder, ILike(field, value).builder...)
+	w.not = false
+
+	return w
+}
+
+func (w *Clause) Contains(field, value string) *Clause {
 	if w.not {
 		field = NOTOperator + field
 	}
 
-	w.builder = append(w.builder, Between(field, value).builder...)
+	w.builder = append(w.builder, Contains(field, value).builder...)
 	w.not = false
 
 	return w
 }
 
+func (w *Clause) StartsWith(field, value string) *Clause {
+	if w.not {
+		field = NOTOperator + field
+	}
+
+	w.builder = append(w.builder, StartsWith(field, value).builder...)
+	w.not = false
+
+	return w
+}
+
+func (w *Clause) EndsWith(field, value string) *Clause {
+	if w.not {
+		field = NOTOperator + field
+	}
+
+	w.builder = append(w.builder, EndsWith(field, value).builder...)
+	w.not = false
+
+	return w
+}
+
+func (w *Clause) Raw(fragment string, args ...any) *Clause {
+	if w.not {
+		fragment = NOTOperator + "(" + fragment + ")"
+	}
+
+	w.builder = append(w.builder, Raw(fragment, args...).builder...)
+	w.not = false
+
+	return w
+}
+
+func (w *Clause) Between(field string, low, high any) *Clause {
+	if w.not {
+		field = NOTOperator + field
+	}
+
+	w.builder = append(w.builder, Between(field, low, high).builder...)
+	w.not = false
+
+	return w
+}
+
+func (w *Clause) IsNull(field string) *Clause {
+	if w.not {
+		field = NOTOperator + field
+	}
+
+	w.builder = append(w.builder, IsNull(field).builder...)
+	w.not = false
+
+	return w
+}
+
+func (w *Clause) IsNotNull(field string) *Clause {
+	if w.not {
+		field = NOTOperator + field
+	}
+
+	w.builder = append(w.builder, IsNotNull(field).builder...)
+	w.not = false
+
+	return w
+}
+
+// RangeOverlap matches rows whose range field overlaps [from, to), rendered as
+// field && tstzrange(?, ?). It is Postgres only, the query failing with
+// ErrUnsupportedDriver on other databases.
+func (w *Clause) RangeOverlap(field string, from, to any) *Clause {
+	if w.not {
+		field = NOTOperator + field
+	}
+
+	w.builder = append(w.builder, RangeOverlap(field, from, to).builder...)
+	w.not = false
+
+	return w
+}
+
+// NullSafeEQ compares field and value treating NULLs as equal, rendered as
+// `<=>` on MySQL and `IS NOT DISTINCT FROM` elsewhere.
+func (w *Clause) NullSafeEQ(field string, value any) *Clause {
+	if w.not {
+		field = NOTOperator + field
+	}
+
+	w.builder = append(w.builder, NullSafeEQ(field, value).builder...)
+	w.not = false
+
+	return w
+}
+
+// Cast returns field cast to castType, usable as the field of any comparison,
+// e.g. GT(w.Cast("code", "int"), 10).
+func (w *Clause) Cast(field, castType string) string {
+	return Cast(field, castType)
+}
+
+// Group appends sub wrapped in parentheses, e.g.
+// Group(EQ("a", 1).OR().EQ("b", 2)).AND().EQ("c", 3) renders (a = ? OR b = ?) AND c = ?.
+func (w *Clause) Group(sub *Clause) *Clause {
+	b := group(sub)
+	if w.not {
+		b.key = NOTOperator + b.key
+	}
+
+	w.builder = append(w.builder, b)
+	w.not = false
+
+	if sub.err != nil {
+		w.err = sub.err
+	}
+
+	return w
+}
+
+// Bind sets the value of every Param named name in the clause.
+func (w *Clause) Bind(name string, value any) *Clause {
+	if w.binds == nil {
+		w.binds = make(map[string]any)
+	}
+
+	w.binds[name] = value
+
+	return w
+}
+
 func (w *Clause) AND() *Clause {
 	w.builder[len(w.builder)-1].nextBoolOP = ANDOperator
 
@@ -138,27 +320,131 @@ func (w *Clause) ToSQL() []any {
 
 	var where string
 
-	for _, clause := range w.builder {
-		if len(clause.operator) > 0 {
-			where += fmt.Sprintf("%s %s ?", clause.key, clause.operator)
-		} else {
-			where += fmt.Sprintf("%s %s", clause.key, clause.operator)
+	builders := collapseEQOr(w.builder)
+
+	for i, clause := range builders {
+		// Boolean operators only join two entries, a trailing one is dropped.
+		if i > 0 {
+			boolOP := strings.TrimSpace(builders[i-1].nextBoolOP)
+			if len(boolOP) == 0 {
+				boolOP = strings.TrimSpace(ANDOperator)
+			}
+
+			where += " " + boolOP + " "
 		}
 
-		if len(clause.nextBoolOP) > 0 {
-			where += clause.nextBoolOP
+		switch {
+		case isUnary(clause.operator):
+			where += fmt.Sprintf("%s %s", clause.key, clause.operator)
+		case len(clause.operator) > 0:
+			where += fmt.Sprintf("%s %s ?", clause.key, dialectOperator(clause.operator))
+		default:
+			where += clause.key
 		}
 
-		if clause.value != nil {
+		if clause.value != nil || (len(clause.operator) > 0 && !isUnary(clause.operator)) {
 			args = append(args, clause.value) //nolint
 		}
+
+		args = append(args, clause.args...)
 	}
 
 	args[0] = where
 
+	for i := 1; i < len(args); i++ {
+		args[i] = w.bind(args[i])
+	}
+
 	return args
 }
 
+// bind replaces bound params in value, which may be a slice as IN takes.
+// Unbound params are kept so an enclosing clause can still bind them.
+func (w *Clause) bind(value any) any {
+	switch v := value.(type) {
+	case Param:
+		if bound, ok := w.binds[string(v)]; ok {
+			return bound
+		}
+	case []any:
+		values := make([]any, len(v))
+		for i := range v {
+			values[i] = w.bind(v[i])
+		}
+
+		return values
+	}
+
+	return value
+}
+
+// AsGorm applies the clause as a Where condition on a raw gorm session.
+func (w *Clause) AsGorm(db *gorm.DB) *gorm.DB {
+	if len(w.builder) == 0 && w.err == nil {
+		return db
+	}
+
+	args := w.ToSQL()
+	if err := w.check(args[1:]); err != nil {
+		_ = db.AddError(err)
+
+		return db
+	}
+
+	return db.Where(args[0], args[1:]...)
+}
+
+// collapseEQOr merges runs of equality predicates on the same field joined by OR
+// into a single IN predicate. A run is only collapsed when it is not bound to its
+// neighbours by AND, since AND takes precedence over OR.
+func collapseEQOr(builders []Builer) []Builer {
+	result := make([]Builer, 0, len(builders))
+
+	for i := 0; i < len(builders); {
+		j := i
+		for j+1 < len(builders) && isCollapsible(builders[j], builders[j+1]) {
+			j++
+		}
+
+		prevAND := i > 0 && joinsByAND(builders[i-1])
+		nextAND := j+1 < len(builders) && joinsByAND(builders[j])
+		if j == i || prevAND || nextAND {
+			result = append(result, builders[i])
+			i++
+
+			continue
+		}
+
+		values := make([]any, 0, j-i+1)
+		for k := i; k <= j; k++ {
+			values = append(values, builders[k].value)
+		}
+
+		result = append(result, Builer{
+			key:        builders[i].key,
+			value:      values,
+			operator:   INOperator,
+			nextBoolOP: builders[j].nextBoolOP,
+		})
+		i = j + 1
+	}
+
+	return result
+}
+
+// joinsByAND reports whether b is joined to the entry after it by AND, which
+// ToSQL also uses when no operator was given.
+func joinsByAND(b Builer) bool {
+	return len(b.nextBoolOP) == 0 || b.nextBoolOP == ANDOperator
+}
+
+func isCollapsible(current, next Builer) bool {
+	return current.nextBoolOP == OROperator &&
+		current.operator == EQOperator && next.operator == EQOperator &&
+		current.key == next.key && !strings.HasPrefix(current.key, NOTOperator) &&
+		current.value != nil && next.value != nil
+}
+
 func EQ(field string, value any) *Clause {
 	return makeWhereClause(EQOperator, field, value)
 }
@@ -179,14 +465,102 @@ func LT(field string, value any) *Clause {
 	return makeWhereClause(LTOperator, field, value)
 }
 
-func Between(field string, value any) *Clause {
-	return makeWhereClause(BetWeen, field, value)
+// Between emits field BETWEEN low AND high, both bounds included.
+func Between(field string, low, high any) *Clause {
+	return &Clause{
+		builder: []Builer{
+			{
+				key:  field + " " + BetWeen + " ? AND ?",
+				args: []any{low, high},
+			},
+		},
+	}
+}
+
+func IsNull(field string) *Clause {
+	return makeWhereClause(IsNullOperator, field, nil)
+}
+
+func IsNotNull(field string) *Clause {
+	return makeWhereClause(IsNotNullOperator, field, nil)
+}
+
+func NullSafeEQ(field string, value any) *Clause {
+	return makeWhereClause(NullSafeEQOperator, field, value)
+}
+
+func RangeOverlap(field string, from, to any) *Clause {
+	return &Clause{
+		builder: []Builer{
+			{
+				key:     field + " && tstzrange(?, ?)",
+				args:    []any{from, to},
+				dialect: "postgres",
+			},
+		},
+	}
 }
 
 func Like(field, value string) *Clause {
 	return makeWhereClause(LikeOperator, field, value)
 }
 
+// ILike matches field against value case-insensitively. It is Postgres only, the
+// query failing with ErrUnsupportedDriver on other databases.
+func ILike(field, value string) *Clause {
+	c := makeWhereClause(ILikeOperator, field, value)
+	c.builder[0].dialect = "postgres"
+
+	return c
+}
+
+// Contains matches field against %value%, the wildcards in value being escaped
+// so that they match literally.
+func Contains(field, value string) *Clause {
+	return likeEscaped(field, "%"+escapeLike(value)+"%")
+}
+
+// StartsWith matches field against value%, escaping the wildcards in value.
+func StartsWith(field, value string) *Clause {
+	return likeEscaped(field, escapeLike(value)+"%")
+}
+
+// EndsWith matches field against %value, escaping the wildcards in value.
+func EndsWith(field, value string) *Clause {
+	return likeEscaped(field, "%"+escapeLike(value))
+}
+
+// likeEscaped emits field LIKE pattern with ! as the escape character, which
+// unlike the backslash needs no quoting on any dialect.
+func likeEscaped(field, pattern string) *Clause {
+	return &Clause{
+		builder: []Builer{
+			{
+				key:  field + " " + LikeOperator + " ? ESCAPE '" + likeEscape + "'",
+				args: []any{pattern},
+			},
+		},
+	}
+}
+
+// Raw is a condition the builder cannot express, rendered verbatim with args
+// bound to its placeholders in order:
+//
+//	EQ("kind", "a").AND().Raw("JSON_EXTRACT(data, '$.x') = ?", 1)
+func Raw(fragment string, args ...any) *Clause {
+	return &Clause{builder: []Builer{{key: fragment, args: args}}}
+}
+
+// Cast renders field cast to castType as field::castType on Postgres and
+// CAST(field AS castType) elsewhere.
+func Cast(field, castType string) string {
+	if dialect() == "postgres" {
+		return field + "::" + castType
+	}
+
+	return "CAST(" + field + " AS " + castType + ")"
+}
+
 // NOTE: Because of golang limition in array of any([]any)
 // IN function used any type for values parameter instead of []any,
 // developers must call IN function with array of any.
@@ -194,6 +568,52 @@ func IN(field string, values any) *Clause {
 	return makeWhereClause(INOperator, field, values)
 }
 
+// NotIn emits field NOT IN ?, values being passed as a single arg for gorm to expand.
+func NotIn(field string, values []any) *Clause {
+	return makeWhereClause(NotINOperator, field, values)
+}
+
+// TupleIn matches rows whose fields, taken together, equal one of rows, as in
+// (a, b) IN ((?, ?), (?, ?)) to look rows up by a composite key. Every row must
+// hold a value per field.
+func TupleIn(fields []string, rows [][]any) *Clause {
+	c := &Clause{}
+
+	if len(fields) == 0 || len(rows) == 0 {
+		c.err = ErrEmptySlice
+
+		return c
+	}
+
+	tuple := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(fields)), ", ") + ")"
+	tuples := make([]string, 0, len(rows))
+	args := make([]any, 0, len(rows)*len(fields))
+
+	for _, row := range rows {
+		if len(row) != len(fields) {
+			c.err = ErrInvalidValue
+
+			return c
+		}
+
+		tuples = append(tuples, tuple)
+		args = append(args, row...)
+	}
+
+	c.builder = []Builer{
+		{
+			key:  "(" + strings.Join(fields, ", ") + ") " + INOperator + " (" + strings.Join(tuples, ", ") + ")",
+			args: args,
+		},
+	}
+
+	return c
+}
+
+func Group(sub *Clause) *Clause {
+	return &Clause{builder: []Builer{group(sub)}, err: sub.err}
+}
+
 func NOT() *Clause {
 	return &Clause{
 		not: true,
@@ -204,6 +624,83 @@ func Search(fields []string, value, operator string) *Clause {
 	return makeWhereClause("", generateTextSearch(fields, value, operator), nil)
 }
 
+// check returns the build error of the clause, ErrUnsupportedDriver for an entry
+// of another database than the one in use, or the first of args left unbound.
+func (w *Clause) check(args []any) error {
+	if w.err != nil {
+		return w.err
+	}
+
+	for _, b := range w.builder {
+		if len(b.dialect) > 0 && b.dialect != dialect() {
+			return ErrUnsupportedDriver
+		}
+	}
+
+	for _, arg := range args {
+		switch v := arg.(type) {
+		case Param:
+			return fmt.Errorf("%w: unbound param %s", ErrInvalidValue, v)
+		case []any:
+			if err := w.check(v); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// AllOf combines clauses with AND, wrapping each one in parentheses.
+func AllOf(clauses ...*Clause) *Clause {
+	return combine(ANDOperator, clauses)
+}
+
+// AnyOf combines clauses with OR, wrapping each one in parentheses.
+func AnyOf(clauses ...*Clause) *Clause {
+	return combine(OROperator, clauses)
+}
+
+func combine(boolOP string, clauses []*Clause) *Clause {
+	result := &Clause{builder: make([]Builer, 0, len(clauses))}
+
+	for _, c := range clauses {
+		if c == nil || len(c.builder) == 0 {
+			continue
+		}
+
+		if c.err != nil {
+			result.err = c.err
+		}
+
+		if len(result.builder) > 0 {
+			result.builder[len(result.builder)-1].nextBoolOP = boolOP
+		}
+
+		result.builder = append(result.builder, group(c))
+	}
+
+	return result
+}
+
+// group renders c as a single parenthesized builder entry, keeping the database
+// its entries are restricted to.
+func group(c *Clause) Builer {
+	sub := c.ToSQL()
+	b := Builer{
+		key:  "(" + sub[0].(string) + ")",
+		args: sub[1:],
+	}
+
+	for _, entry := range c.builder {
+		if len(entry.dialect) > 0 {
+			b.dialect = entry.dialect
+		}
+	}
+
+	return b
+}
+
 func makeWhereClause(operator, field string, value any) *Clause {
 	return &Clause{
 		builder: []Builer{
@@ -234,6 +731,28 @@ func generateTextSearch(columns []string, input, operator string) (res string) {
 	return
 }
 
+// isUnary reports whether operator takes no value, and so no placeholder.
+func isUnary(operator string) bool {
+	return operator == IsNullOperator || operator == IsNotNullOperator
+}
+
+// dialectOperator renders operators whose spelling depends on the database in use.
+func dialectOperator(operator string) string {
+	if operator == NullSafeEQOperator && dialect() != "mysql" {
+		return "IS NOT DISTINCT FROM"
+	}
+
+	return operator
+}
+
+func escapeLike(value string) string {
+	return strings.NewReplacer(
+		likeEscape, likeEscape+likeEscape,
+		"%", likeEscape+"%",
+		"_", likeEscape+"_",
+	).Replace(value)
+}
+
 func removeMultipleSpace(input string) string {
 	space := regexp.MustCompile(`\s+`)
 
@@ -241,17 +760,24 @@ func removeMultipleSpace(input string) string {
 }
 
 const (
-	EQOperator   = "="
-	GTOperator   = ">"
-	GTEOperator  = ">="
-	LTOperator   = "<"
-	LTEOperator  = "<="
-	INOperator   = "IN"
-	LikeOperator = "LIKE"
-	BetWeen      = "BETWEEN"
-	NOTOperator  = "NOT "
-	OROperator   = "OR "
-	ANDOperator  = "AND "
-	ASCOperator  = " ASC"
-	DESCOperator = " DESC"
+	EQOperator         = "="
+	GTOperator         = ">"
+	GTEOperator        = ">="
+	LTOperator         = "<"
+	LTEOperator        = "<="
+	INOperator         = "IN"
+	NotINOperator      = "NOT IN"
+	LikeOperator       = "LIKE"
+	ILikeOperator      = "ILIKE"
+	BetWeen            = "BETWEEN"
+	NullSafeEQOperator = "<=>"
+	IsNullOperator     = "IS NULL"
+	IsNotNullOperator  = "IS NOT NULL"
+	NOTOperator        = "NOT "
+	OROperator         = "OR "
+	ANDOperator        = "AND "
+	ASCOperator        = " ASC"
+	DESCOperator       = " DESC"
+
+	likeEscape = "!"
 )
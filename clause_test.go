@@ -0,0 +1,228 @@
+package entigorm
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestToSQL(t *testing.T) {
+	tests := []struct {
+		name   string
+		clause *Clause
+		sql    string
+		args   []any
+	}{
+		{
+			name:   "range overlap",
+			clause: RangeOverlap("during", 1, 2),
+			sql:    "during && tstzrange(?, ?)",
+			args:   []any{1, 2},
+		},
+		{
+			name:   "not range overlap",
+			clause: NOT().RangeOverlap("during", 1, 2),
+			sql:    "NOT during && tstzrange(?, ?)",
+			args:   []any{1, 2},
+		},
+		{
+			name:   "ilike",
+			clause: ILike("name", "an%"),
+			sql:    "name ILIKE ?",
+			args:   []any{"an%"},
+		},
+		{
+			name:   "not ilike",
+			clause: EQ("id", 1).AND().NOT().ILike("name", "an%"),
+			sql:    "id = ? AND NOT name ILIKE ?",
+			args:   []any{1, "an%"},
+		},
+		{
+			name:   "tuple in",
+			clause: TupleIn([]string{"a", "b"}, [][]any{{1, "x"}, {2, "y"}}),
+			sql:    "(a, b) IN ((?, ?), (?, ?))",
+			args:   []any{1, "x", 2, "y"},
+		},
+		{
+			name:   "not tuple in",
+			clause: EQ("id", 1).AND().NOT().TupleIn([]string{"a", "b"}, [][]any{{1, "x"}}),
+			sql:    "id = ? AND NOT (a, b) IN ((?, ?))",
+			args:   []any{1, 1, "x"},
+		},
+		{
+			name:   "collapsed or",
+			clause: EQ("status", 1).OR().EQ("status", 2).OR().EQ("status", 3),
+			sql:    "status IN ?",
+			args:   []any{[]any{1, 2, 3}},
+		},
+		{
+			name:   "or bound by implicit and prefix",
+			clause: EQ("a", 1).EQ("s", "x").OR().EQ("s", "y"),
+			sql:    "a = ? AND s = ? OR s = ?",
+			args:   []any{1, "x", "y"},
+		},
+		{
+			name:   "or bound by implicit and suffix",
+			clause: EQ("s", "x").OR().EQ("s", "y").EQ("a", 1),
+			sql:    "s = ? OR s = ? AND a = ?",
+			args:   []any{"x", "y", 1},
+		},
+		{
+			name:   "or bound by and",
+			clause: EQ("status", 1).OR().EQ("status", 2).AND().EQ("id", 3),
+			sql:    "status = ? OR status = ? AND id = ?",
+			args:   []any{1, 2, 3},
+		},
+		{
+			name:   "all of",
+			clause: AllOf(EQ("a", 1), EQ("b", 2).OR().EQ("c", 3), nil, GT("d", 4)),
+			sql:    "(a = ?) AND (b = ? OR c = ?) AND (d > ?)",
+			args:   []any{1, 2, 3, 4},
+		},
+		{
+			name:   "any of",
+			clause: AnyOf(EQ("a", 1), EQ("b", 2).AND().EQ("c", 3), LT("d", 4)),
+			sql:    "(a = ?) OR (b = ? AND c = ?) OR (d < ?)",
+			args:   []any{1, 2, 3, 4},
+		},
+		{
+			name:   "is null",
+			clause: IsNull("deleted_at"),
+			sql:    "deleted_at IS NULL",
+			args:   []any{},
+		},
+		{
+			name:   "is not null",
+			clause: EQ("id", 1).AND().IsNotNull("deleted_at"),
+			sql:    "id = ? AND deleted_at IS NOT NULL",
+			args:   []any{1},
+		},
+		{
+			name:   "bound param",
+			clause: EQ("first_name", Param("q")).OR().EQ("last_name", Param("q")).Bind("q", "ann"),
+			sql:    "first_name = ? OR last_name = ?",
+			args:   []any{"ann", "ann"},
+		},
+		{
+			name:   "three chained",
+			clause: EQ("a", 1).AND().EQ("b", 2).OR().EQ("c", 3),
+			sql:    "a = ? AND b = ? OR c = ?",
+			args:   []any{1, 2, 3},
+		},
+		{
+			name:   "trailing operator",
+			clause: EQ("a", 1).AND(),
+			sql:    "a = ?",
+			args:   []any{1},
+		},
+		{
+			name:   "contains",
+			clause: Contains("name", "50%_off"),
+			sql:    "name LIKE ? ESCAPE '!'",
+			args:   []any{"%50!%!_off%"},
+		},
+		{
+			name:   "starts with",
+			clause: StartsWith("name", "an"),
+			sql:    "name LIKE ? ESCAPE '!'",
+			args:   []any{"an%"},
+		},
+		{
+			name:   "ends with",
+			clause: EQ("id", 1).AND().EndsWith("name", "n!"),
+			sql:    "id = ? AND name LIKE ? ESCAPE '!'",
+			args:   []any{1, "%n!!"},
+		},
+		{
+			name:   "raw",
+			clause: EQ("kind", "a").AND().Raw("JSON_EXTRACT(data, '$.x') BETWEEN ? AND ?", 1, 2).OR().EQ("id", 3),
+			sql:    "kind = ? AND JSON_EXTRACT(data, '$.x') BETWEEN ? AND ? OR id = ?",
+			args:   []any{"a", 1, 2, 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.clause.ToSQL()
+			if got[0] != tt.sql || !reflect.DeepEqual(got[1:], tt.args) {
+				t.Fatalf("ToSQL() = %q %v, want %q %v", got[0], got[1:], tt.sql, tt.args)
+			}
+		})
+	}
+}
+
+func TestPostgresOnlyClauses(t *testing.T) {
+	// Built before any database is set, the dialect is only resolved when applied.
+	clauses := map[string]*Clause{
+		"ilike":         ILike("name", "an%"),
+		"range overlap": RangeOverlap("during", 1, 2),
+		"grouped":       AnyOf(EQ("id", 1), ILike("name", "an%")),
+	}
+
+	openDryRunDB(t, "postgres")
+
+	for name, c := range clauses {
+		if err := c.check(nil); err != nil {
+			t.Errorf("%s on postgres: %v", name, err)
+		}
+	}
+
+	openDryRunDB(t, "mysql")
+
+	for name, c := range clauses {
+		if err := c.check(nil); !errors.Is(err, ErrUnsupportedDriver) {
+			t.Errorf("%s on mysql = %v, want ErrUnsupportedDriver", name, err)
+		}
+	}
+}
+
+func TestNullSafeEQ(t *testing.T) {
+	for dialect, sql := range map[string]string{
+		"mysql":    "deleted_by <=> ?",
+		"postgres": "deleted_by IS NOT DISTINCT FROM ?",
+	} {
+		openDryRunDB(t, dialect)
+
+		for _, value := range []any{7, nil} {
+			got := NullSafeEQ("deleted_by", value).ToSQL()
+			if got[0] != sql || !reflect.DeepEqual(got[1:], []any{value}) {
+				t.Errorf("%s: ToSQL() = %q %v, want %q [%v]", dialect, got[0], got[1:], sql, value)
+			}
+		}
+	}
+}
+
+func TestCast(t *testing.T) {
+	for dialect, sql := range map[string]string{
+		"postgres": "code::int > ?",
+		"mysql":    "CAST(code AS int) > ?",
+	} {
+		openDryRunDB(t, dialect)
+
+		got := GT(Cast("code", "int"), 10).ToSQL()
+		if got[0] != sql || !reflect.DeepEqual(got[1:], []any{10}) {
+			t.Errorf("%s: ToSQL() = %q %v, want %q [10]", dialect, got[0], got[1:], sql)
+		}
+	}
+}
+
+func TestAsGorm(t *testing.T) {
+	gormdb := openTestDB(t, &testUser{})
+	insertUsers(t, "ann", "bob", "cid")
+
+	var names []string
+
+	err := EQ("name", "ann").OR().GT("age", 2).AsGorm(gormdb.Model(&testUser{})).Order("id").Pluck("name", &names).Error
+	if err != nil {
+		t.Fatalf("Pluck: %v", err)
+	}
+
+	if !reflect.DeepEqual(names, []string{"ann", "cid"}) {
+		t.Fatalf("AsGorm query = %v, want ann and cid", names)
+	}
+
+	err = ILike("name", "a%").AsGorm(gormdb.Model(&testUser{})).Pluck("name", &names).Error
+	if !errors.Is(err, ErrUnsupportedDriver) {
+		t.Fatalf("AsGorm with ILike on SQLite = %v, want ErrUnsupportedDriver", err)
+	}
+}
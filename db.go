@@ -1,21 +1,243 @@
 package entigorm
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
 
+	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/plugin/dbresolver"
 )
 
-var db *gorm.DB
+var (
+	db              *gorm.DB
+	closed          bool
+	defaultLimit    int
+	writeHooks      []func(ctx context.Context, op, table string, rowsAffected int64)
+	replicaFallback bool
+)
+
+// Connect opens a connection with dialector and makes it the package db.
+func Connect(dialector gorm.Dialector, opts ...gorm.Option) error {
+	gormdb, err := gorm.Open(dialector, opts...)
+	if err != nil {
+		return err
+	}
+
+	Init(gormdb)
+
+	return nil
+}
+
+// EnablePreparedStatements is a Connect option preparing every statement once
+// and reusing it afterwards. The statement cache belongs to the package db, so
+// it is shared by all entities.
+func EnablePreparedStatements() gorm.Option {
+	return preparedStatements{}
+}
+
+type preparedStatements struct{}
+
+func (preparedStatements) Apply(config *gorm.Config) error {
+	config.PrepareStmt = true
 
+	return nil
+}
+
+func (preparedStatements) AfterInitialize(*gorm.DB) error {
+	return nil
+}
+
+// SetDB makes an already configured gorm handle the package db, like Init.
+func SetDB(gormdb *gorm.DB) {
+	Init(gormdb)
+}
+
+// Init makes gormdb the package db, a nil gormdb resetting it.
 func Init(gormdb *gorm.DB) {
 	db = gormdb
+	closed = false
+
+	if gormdb == nil {
+		return
+	}
+
+	if err := registerCallbacks(gormdb); err != nil {
+		logger.Default.Error(context.Background(), "entigorm: registering callbacks: %v", err)
+	}
+}
+
+// SetDefaultLimit applies a LIMIT of n to every Find that did not call Limit or NoLimit.
+// A value <= 0 disables the default limit.
+func SetDefaultLimit(n int) {
+	defaultLimit = n
+}
+
+// RegisterReplicas routes reads outside a transaction, Find, One, Count and the
+// like, to one of replicas picked at random, while writes and transactions keep
+// using the primary. Use ReadFresh for reads that must see a preceding write.
+func RegisterReplicas(replicas ...gorm.Dialector) error {
+	if err := ready(); err != nil {
+		return err
+	}
+
+	return db.Use(dbresolver.Register(dbresolver.Config{
+		Replicas: replicas,
+		Policy:   dbresolver.RandomPolicy{},
+	}))
+}
+
+// dialect returns the name of the configured database dialect, e.g. "postgres" or "mysql".
+func dialect() string {
+	if db == nil || db.Dialector == nil {
+		return ""
+	}
+
+	return db.Dialector.Name()
+}
+
+// SetReplicaFallback makes reads that fail with a connection error on a replica
+// retry once against the primary, logging the fallback.
+func SetReplicaFallback(enabled bool) {
+	replicaFallback = enabled
+}
+
+// isConnError reports whether err comes from a broken or unreachable connection.
+func isConnError(err error) bool {
+	var netErr net.Error
+
+	return errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) || errors.As(err, &netErr)
+}
+
+// takeConn takes a connection from the pool tx runs on, failing with
+// ErrPoolExhausted when the context of tx ends first, so callers can shed load
+// instead of treating it as a slow statement. It returns nil when tx does not run
+// on a pool, as within a transaction or with EnablePreparedStatements.
+func takeConn(tx *gorm.DB) (*sql.Conn, error) {
+	pool, ok := tx.Statement.ConnPool.(*sql.DB)
+	if !ok {
+		return nil, nil
+	}
+
+	conn, err := pool.Conn(tx.Statement.Context)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return nil, fmt.Errorf("%w: %w", ErrPoolExhausted, err)
+	}
+
+	return conn, err
+}
+
+// begin starts a transaction of the package db on a connection taken by
+// takeConn, which goes back to the pool once the transaction ends.
+func begin(ctx context.Context) *gorm.DB {
+	tx := db.WithContext(ctx)
+
+	conn, err := takeConn(tx)
+	if err != nil {
+		_ = tx.AddError(err)
+
+		return tx
+	}
+
+	if conn == nil {
+		return tx.Begin()
+	}
+
+	tx.Statement.ConnPool = conn
+	tx = tx.Begin()
+
+	// Close waits for the transaction to end before releasing the connection.
+	go conn.Close() //nolint:errcheck
+
+	return tx
+}
+
+// OnWrite registers fn to be called after every successful write, op being one of
+// insert, update, upsert, delete or truncate. Writes made inside a transaction are
+// reported only once it commits.
+func OnWrite(fn func(ctx context.Context, op, table string, rowsAffected int64)) {
+	writeHooks = append(writeHooks, fn)
+}
+
+// mariaDB reports whether the package db runs on MariaDB, from the server version
+// the mysql dialector read when connecting.
+func mariaDB() bool {
+	var config *mysql.Config
+
+	switch d := db.Dialector.(type) {
+	case *mysql.Dialector:
+		config = d.Config
+	case mysql.Dialector:
+		config = d.Config
+	}
+
+	return config != nil && strings.Contains(config.ServerVersion, "MariaDB")
 }
 
 func Connection() (*sql.DB, error) {
+	if err := ready(); err != nil {
+		return nil, err
+	}
+
 	return db.DB()
 }
 
+// Close closes the connection pool of the package db, after which every
+// operation fails with ErrClosed until Init is called again.
+func Close() error {
+	if err := ready(); err != nil {
+		return err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+
+	closed = true
+
+	return sqlDB.Close()
+}
+
+// ConfigurePool tunes the connection pool of the package db.
+func ConfigurePool(maxOpen, maxIdle int, connMaxLifetime time.Duration) error {
+	if err := ready(); err != nil {
+		return err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+
+	sqlDB.SetMaxOpenConns(maxOpen)
+	sqlDB.SetMaxIdleConns(maxIdle)
+	sqlDB.SetConnMaxLifetime(connMaxLifetime)
+
+	return nil
+}
+
+// ready reports whether the package db can serve queries.
+func ready() error {
+	if closed {
+		return ErrClosed
+	}
+
+	if db == nil {
+		return ErrInvalidDB
+	}
+
+	return nil
+}
+
 var (
 	// ErrRecordNotFound record not found error.
 	ErrRecordNotFound = gorm.ErrRecordNotFound
@@ -57,4 +279,84 @@ var (
 	ErrPreloadNotAllowed = gorm.ErrPreloadNotAllowed
 	// ErrDuplicatedKey occurs when there is a unique key constraint violation.
 	ErrDuplicatedKey = gorm.ErrDuplicatedKey
+	// ErrLockRequiresTx locking clause used outside of a transaction.
+	ErrLockRequiresTx = errors.New("lock requires a transaction")
+	// ErrPoolExhausted context deadline exceeded while every pooled connection was busy.
+	ErrPoolExhausted = errors.New("connection pool exhausted")
+	// ErrClosed database used after Close.
+	ErrClosed = errors.New("database is closed")
 )
+
+var httpStatuses = []struct {
+	err    error
+	status int
+}{
+	{ErrRecordNotFound, http.StatusNotFound},
+	{ErrDuplicatedKey, http.StatusConflict},
+	{ErrMissingWhereClause, http.StatusBadRequest},
+	{ErrPrimaryKeyRequired, http.StatusBadRequest},
+	{ErrModelValueRequired, http.StatusBadRequest},
+	{ErrInvalidData, http.StatusBadRequest},
+	{ErrInvalidField, http.StatusBadRequest},
+	{ErrEmptySlice, http.StatusBadRequest},
+	{ErrInvalidValue, http.StatusBadRequest},
+	{ErrInvalidValueOfLength, http.StatusBadRequest},
+	{ErrPreloadNotAllowed, http.StatusBadRequest},
+	{ErrNotImplemented, http.StatusNotImplemented},
+	{ErrUnsupportedDriver, http.StatusNotImplemented},
+	{ErrDryRunModeUnsupported, http.StatusNotImplemented},
+	{ErrPoolExhausted, http.StatusServiceUnavailable},
+	{ErrClosed, http.StatusServiceUnavailable},
+	{context.DeadlineExceeded, http.StatusGatewayTimeout},
+}
+
+// HTTPError maps err to an HTTP status code and a message safe to return to
+// clients. Errors that match no sentinel map to 500 without leaking their text.
+func HTTPError(err error) (status int, message string) {
+	if err == nil {
+		return http.StatusOK, ""
+	}
+
+	for _, s := range httpStatuses {
+		if errors.Is(err, s.err) {
+			return s.status, s.err.Error()
+		}
+	}
+
+	return http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError)
+}
+
+// AdvisoryLock blocks until the Postgres session-level advisory lock key is held.
+// The lock lives on a connection dedicated to it until unlock is called, which
+// releases the lock and returns the connection to the pool.
+func AdvisoryLock(ctx context.Context, key int64) (unlock func() error, err error) {
+	if err := ready(); err != nil {
+		return nil, err
+	}
+
+	if dialect() != "postgres" {
+		return nil, ErrUnsupportedDriver
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", key); err != nil {
+		return nil, errors.Join(err, conn.Close())
+	}
+
+	unlock = func() error {
+		_, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", key)
+
+		return errors.Join(err, conn.Close())
+	}
+
+	return unlock, nil
+}
@@ -0,0 +1,224 @@
+package entigorm
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func BenchmarkPreparedStatements(b *testing.B) {
+	modes := map[string][]gorm.Option{
+		"plain":    {&gorm.Config{Logger: logger.Default.LogMode(logger.Silent)}},
+		"prepared": {&gorm.Config{Logger: logger.Default.LogMode(logger.Silent)}, EnablePreparedStatements()},
+	}
+
+	for name, opts := range modes {
+		b.Run(name, func(b *testing.B) {
+			if err := Connect(sqlite.Open("file::memory:"), opts...); err != nil {
+				b.Fatalf("connect: %v", err)
+			}
+
+			sqlDB, err := db.DB()
+			if err != nil {
+				b.Fatalf("pool: %v", err)
+			}
+
+			sqlDB.SetMaxOpenConns(1)
+			b.Cleanup(func() {
+				sqlDB.Close()
+				resetGlobals()
+			})
+
+			if err := db.AutoMigrate(&testUser{}); err != nil {
+				b.Fatalf("migrate: %v", err)
+			}
+
+			if err := SQL(&testUser{Name: "ann", Age: 30}).Insert(context.Background()); err != nil {
+				b.Fatalf("insert: %v", err)
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				if _, err := SQL(&testUser{}).Where(EQ("name", "ann")).Find(context.Background()); err != nil {
+					b.Fatalf("Find: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestHTTPError(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+	}{
+		{nil, http.StatusOK},
+		{ErrRecordNotFound, http.StatusNotFound},
+		{ErrDuplicatedKey, http.StatusConflict},
+		{ErrMissingWhereClause, http.StatusBadRequest},
+		{ErrPrimaryKeyRequired, http.StatusBadRequest},
+		{ErrModelValueRequired, http.StatusBadRequest},
+		{ErrInvalidData, http.StatusBadRequest},
+		{ErrInvalidField, http.StatusBadRequest},
+		{ErrEmptySlice, http.StatusBadRequest},
+		{ErrInvalidValue, http.StatusBadRequest},
+		{ErrInvalidValueOfLength, http.StatusBadRequest},
+		{ErrPreloadNotAllowed, http.StatusBadRequest},
+		{ErrNotImplemented, http.StatusNotImplemented},
+		{ErrUnsupportedDriver, http.StatusNotImplemented},
+		{ErrDryRunModeUnsupported, http.StatusNotImplemented},
+		{ErrClosed, http.StatusServiceUnavailable},
+		{fmt.Errorf("%w: %w", ErrPoolExhausted, context.DeadlineExceeded), http.StatusServiceUnavailable},
+		{context.DeadlineExceeded, http.StatusGatewayTimeout},
+		{errors.New("pq: password authentication failed"), http.StatusInternalServerError},
+	}
+
+	for _, test := range tests {
+		err := test.err
+		if err != nil {
+			err = fmt.Errorf("find users: %w", err)
+		}
+
+		status, message := HTTPError(err)
+
+		if status != test.status {
+			t.Errorf("HTTPError(%v) status = %d, want %d", test.err, status, test.status)
+		}
+
+		if status == http.StatusInternalServerError && message != http.StatusText(status) {
+			t.Errorf("HTTPError(%v) leaks %q", test.err, message)
+		}
+	}
+}
+
+// recordingConnector makes connections recording the statements executed on
+// them, standing in for a database the tests cannot run.
+type recordingConnector struct {
+	execs []string
+}
+
+func (c *recordingConnector) Connect(context.Context) (driver.Conn, error) {
+	return recordingConn{c}, nil
+}
+
+func (c *recordingConnector) Driver() driver.Driver { return nil }
+
+type recordingConn struct {
+	connector *recordingConnector
+}
+
+func (recordingConn) Prepare(string) (driver.Stmt, error) { return nil, ErrNotImplemented }
+func (recordingConn) Close() error                        { return nil }
+func (recordingConn) Begin() (driver.Tx, error)           { return nil, ErrNotImplemented }
+
+func (c recordingConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	values := make([]any, 0, len(args))
+	for _, arg := range args {
+		values = append(values, arg.Value)
+	}
+
+	c.connector.execs = append(c.connector.execs, fmt.Sprint(query, " ", values))
+
+	return driver.RowsAffected(0), nil
+}
+
+// openRecordingDB makes a db reporting dialect and recording its statements
+// the package db for the duration of t.
+func openRecordingDB(t *testing.T, dialect string) (*recordingConnector, *sql.DB) {
+	t.Helper()
+
+	connector := &recordingConnector{}
+	sqlDB := sql.OpenDB(connector)
+
+	gormdb, err := gorm.Open(namedDialector{name: dialect}, &gorm.Config{ConnPool: sqlDB, Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	Init(gormdb)
+	t.Cleanup(func() {
+		sqlDB.Close()
+		resetGlobals()
+	})
+
+	return connector, sqlDB
+}
+
+func TestAdvisoryLock(t *testing.T) {
+	connector, sqlDB := openRecordingDB(t, "postgres")
+
+	unlock, err := AdvisoryLock(context.Background(), 42)
+	if err != nil {
+		t.Fatalf("AdvisoryLock: %v", err)
+	}
+
+	if inUse := sqlDB.Stats().InUse; inUse != 1 {
+		t.Fatalf("%d connections in use while locked, want the lock's own", inUse)
+	}
+
+	if err := unlock(); err != nil {
+		t.Fatalf("unlock: %v", err)
+	}
+
+	if inUse := sqlDB.Stats().InUse; inUse != 0 {
+		t.Fatalf("%d connections in use after unlock, want 0", inUse)
+	}
+
+	want := []string{"SELECT pg_advisory_lock($1) [42]", "SELECT pg_advisory_unlock($1) [42]"}
+	if !reflect.DeepEqual(connector.execs, want) {
+		t.Fatalf("executed %q, want %q", connector.execs, want)
+	}
+
+	openTestDB(t)
+
+	if _, err := AdvisoryLock(context.Background(), 42); !errors.Is(err, ErrUnsupportedDriver) {
+		t.Fatalf("AdvisoryLock on SQLite = %v, want ErrUnsupportedDriver", err)
+	}
+}
+
+func TestClose(t *testing.T) {
+	openTestDB(t, &testUser{})
+
+	if err := Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	ctx := context.Background()
+
+	if _, err := SQL(&testUser{}).Find(ctx); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Find after Close = %v, want ErrClosed", err)
+	}
+
+	if err := SQL(&testUser{Name: "ann"}).Insert(ctx); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Insert after Close = %v, want ErrClosed", err)
+	}
+
+	if err := WithTransaction(ctx, func(Transaction) error { return nil }); !errors.Is(err, ErrClosed) {
+		t.Fatalf("WithTransaction after Close = %v, want ErrClosed", err)
+	}
+
+	if err := Close(); !errors.Is(err, ErrClosed) {
+		t.Fatalf("second Close = %v, want ErrClosed", err)
+	}
+}
+
+func TestInitNil(t *testing.T) {
+	openTestDB(t, &testUser{})
+
+	Init(nil)
+
+	if _, err := SQL(&testUser{}).Find(context.Background()); !errors.Is(err, ErrInvalidDB) {
+		t.Fatalf("Find after Init(nil) = %v, want ErrInvalidDB", err)
+	}
+}
@@ -2,57 +2,137 @@ package entigorm
 
 import (
 	"context"
+	"database/sql"
 	"errors"
+	"fmt"
 	"reflect"
+	"regexp"
+	"sort"
 	"strings"
+	"time"
 
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+	"gorm.io/plugin/dbresolver"
 )
 
 type Entitier[E entity] interface {
 	QueryMaker[E]
 	QueryConsumer[E]
 	RawExecutor[E]
+	Subquery
 
 	SetTx(tx Transaction, commit bool) Entitier[E]
+	WithFieldCipher(column string, cipher Cipher) Entitier[E]
 }
 
 type QueryMaker[E entity] interface {
 	Where(*Clause) Entitier[E]
+	WhereIf(cond bool, whereClause *Clause) Entitier[E]
+	OrWhere(*Clause) Entitier[E]
+	WhereJSON(raw []byte) (Entitier[E], error)
 	Having(*Clause) Entitier[E]
 	Select(cols ...string) Entitier[E]
+	SelectExpr(expr string, args ...any) Entitier[E]
+	SelectCoalesce(column string, defaultVal any, alias string) Entitier[E]
+	SelectStringAgg(column, sep, alias string) Entitier[E]
+	SelectExists(alias string, sub Subquery) Entitier[E]
 	Offset(int) Entitier[E]
 	Limit(int) Entitier[E]
-	OrderBy(name string, desc bool) Entitier[E]
+	NoLimit() Entitier[E]
+	OrderBy(name string, ascending bool) Entitier[E]
+	OrderByMany(cols ...OrderColumn) Entitier[E]
+	OrderByCI(column string, desc bool) Entitier[E]
+	After(cursorColumn string, cursorValue any, desc bool) Entitier[E]
+	AfterKeys(cursorColumns []string, cursorValues []any, desc bool) Entitier[E]
 	GroupBy(string) Entitier[E]
+	Distinct(cols ...string) Entitier[E]
 	ToSQL() []any
 	IsMany() Entitier[E]
+	Clone() Entitier[E]
 	Join(any) Entitier[E]
+	LeftJoin(any) Entitier[E]
+	InnerJoin(any) Entitier[E]
+	JoinPreload(assoc string, conds ...*Clause) Entitier[E]
+	Preload(assoc string, conds ...*Clause) Entitier[E]
+	ReadFresh() Entitier[E]
+	Timeout(d time.Duration) Entitier[E]
+	Unscoped() Entitier[E]
+	AsOf(t time.Time) Entitier[E]
+	As(alias string) Entitier[E]
+	Lock(strength string) Entitier[E]
+	LockOf(tables ...string) Entitier[E]
+	ForUpdate() Entitier[E]
+	ForShare() Entitier[E]
+	CascadeSoftDelete(assocs ...string) Entitier[E]
+	PerChunkTx() Entitier[E]
+	InsertColumns(cols ...string) Entitier[E]
+	Returning(cols ...string) Entitier[E]
 }
 
 type QueryConsumer[E entity] interface {
 	Find(context.Context) ([]E, error)
 	One(context.Context) (E, error)
+	Last(context.Context) (E, error)
+	ByIDsUnique(ctx context.Context, ids []any) ([]E, error)
+	ModifiedSince(ctx context.Context, column string, since time.Time) ([]E, error)
+	TopNPerGroup(ctx context.Context, partitionCol, orderCol string, n int, desc bool) ([]E, error)
 	Count(context.Context) (int64, error)
+	Counts(ctx context.Context, specs ...CountSpec) (map[string]int64, error)
+	Sum(ctx context.Context, column string) (float64, error)
+	Avg(ctx context.Context, column string) (float64, error)
+	Max(ctx context.Context, column string) (float64, error)
+	Min(ctx context.Context, column string) (float64, error)
+	Exists(context.Context) (bool, error)
+	ExistsBy(ctx context.Context, column string, value any) (bool, error)
+	Paginate(ctx context.Context, page, pageSize int) (items []E, total int64, err error)
+	Rows(context.Context) (*sql.Rows, error)
+	FindInBatches(ctx context.Context, batchSize int, fn func(batch []E) error) error
+	ScanInto(ctx context.Context, dest any) error
+	Explain(context.Context) (string, error)
+	ExplainAnalyze(context.Context) (string, error)
+	ExplainSQL(context.Context) string
 
 	Insert(context.Context) error
+	FirstOrCreate(context.Context) (E, error)
 	InsertBatch(context.Context, []E) error
+	InsertBatchReturning(context.Context, []E) ([]any, error)
 	Update(context.Context) error
+	UpdateResult(context.Context) (int64, error)
+	Save(context.Context) error
+	UpdateColumns(ctx context.Context, values map[string]any) error
 	Delete(context.Context) error
+	DeleteResult(context.Context) (int64, error)
+	DeletePermanently(context.Context) error
+	Upsert(ctx context.Context, conflictColumns, updateColumns []string, guard ...*Clause) error
+	UpsertBatch(ctx context.Context, entities []E, conflictColumns, updateColumns []string, chunkSize int) error
+	UpdateCaseByID(ctx context.Context, column string, values map[any]any) (int64, error)
+	UpdateFrom(ctx context.Context, sub Subquery, assignments map[string]any, on *Clause) (int64, error)
 
 	InsertTx(context.Context) (Transaction, error)
 	UpdateTx(context.Context) (Transaction, error)
 	DeleteTx(context.Context) (Transaction, error)
+
+	Truncate(context.Context) error
+	TruncateCascade(context.Context) error
 }
 
 type RawExecutor[E entity] interface {
 	Query(sql string, values ...any) error
 	QueryRows(sql string, values ...any) ([]E, error)
+	QueryRowsCtx(ctx context.Context, sql string, values ...any) ([]E, error)
 	Exec(sql string, values ...any) error
 }
 
+// Subquery is a query usable as a derived table, as UpdateFrom does. Every
+// Entitier is one, aliased by As or "s" by default.
+type Subquery interface {
+	subquery(ctx context.Context) (query *gorm.DB, alias string, err error)
+}
+
 type entity interface {
 	TableName() string
 }
@@ -60,19 +140,236 @@ type entity interface {
 type Transaction interface {
 	implement()
 	Commit() error
+	// Rollback aborts the transaction, it fails with ErrInvalidTransaction once
+	// the transaction was committed or rolled back.
+	Rollback() error
+	// IsActive reports whether the transaction is neither committed nor rolled back.
+	IsActive() bool
+	// RowsAffected returns the rows changed by the InsertTx, UpdateTx or DeleteTx that returned it.
+	RowsAffected() int64
+	// DeferConstraints defers deferrable constraint checks until commit. It is Postgres only.
+	DeferConstraints() error
+	// Nested runs fn within a savepoint, rolling back to it when fn fails or
+	// panics while keeping the outer transaction alive. A write failing within fn
+	// rolls back to the savepoint too.
+	Nested(ctx context.Context, fn func(tx Transaction) error) error
+	// SavePoint creates the savepoint name. A write failing afterwards rolls back
+	// to the latest savepoint instead of aborting the whole transaction.
+	SavePoint(name string) error
+	// RollbackTo undoes the changes made since the savepoint name.
+	RollbackTo(name string) error
 }
 
 type transaction struct {
-	scopes    []func(*gorm.DB) *gorm.DB
-	tx        *gorm.DB
-	commit    bool
-	savePoint string
+	scopes       []func(*gorm.DB) *gorm.DB
+	tx           *gorm.DB
+	commit       bool
+	savePoint    string
+	done         bool
+	root         *transaction
+	afterCommit  []func()
+	rowsAffected int64
+	savePoints   int
+	// queued records, by savepoint name, how many afterCommit notifications were
+	// queued when it was created, those queued after being dropped on rollback to it.
+	queued map[string]int
 }
 
 func (t *transaction) implement() {}
 
 func (t *transaction) Commit() error {
-	return t.tx.Commit().Error
+	owner := t.owner()
+	if !owner.IsActive() {
+		return ErrInvalidTransaction
+	}
+
+	err := owner.tx.Commit().Error
+	if err != nil {
+		return err
+	}
+
+	owner.done = true
+
+	for _, fn := range owner.afterCommit {
+		fn()
+	}
+
+	owner.afterCommit = nil
+
+	return nil
+}
+
+func (t *transaction) Rollback() error {
+	owner := t.owner()
+	if !owner.IsActive() {
+		return ErrInvalidTransaction
+	}
+
+	if err := owner.tx.Rollback().Error; err != nil {
+		return err
+	}
+
+	owner.done = true
+	owner.afterCommit = nil
+
+	return nil
+}
+
+func (t *transaction) IsActive() bool {
+	owner := t.owner()
+
+	return owner.tx != nil && !owner.done
+}
+
+func (t *transaction) RowsAffected() int64 {
+	return t.rowsAffected
+}
+
+func (t *transaction) DeferConstraints() error {
+	if dialect() != "postgres" {
+		return ErrUnsupportedDriver
+	}
+
+	return t.owner().tx.Exec("SET CONSTRAINTS ALL DEFERRED").Error
+}
+
+func (t *transaction) Nested(ctx context.Context, fn func(tx Transaction) error) (err error) {
+	owner := t.owner()
+	owner.savePoints++
+	name := fmt.Sprintf("entigorm_sp_%d", owner.savePoints)
+
+	if err := owner.tx.WithContext(ctx).SavePoint(name).Error; err != nil {
+		return err
+	}
+
+	if owner.queued == nil {
+		owner.queued = make(map[string]int)
+	}
+
+	owner.queued[name] = len(owner.afterCommit)
+	previous := owner.savePoint
+	owner.savePoint = name
+	panicked := true
+
+	defer func() {
+		owner.savePoint = previous
+
+		if panicked || err != nil {
+			if rerr := owner.tx.WithContext(ctx).RollbackTo(name).Error; rerr != nil {
+				err = errors.Join(err, rerr)
+			} else {
+				owner.dropQueued(name)
+			}
+		}
+	}()
+
+	err = fn(owner)
+	panicked = false
+
+	return err
+}
+
+func (t *transaction) SavePoint(name string) error {
+	owner := t.owner()
+	if !owner.IsActive() {
+		return ErrInvalidTransaction
+	}
+
+	if err := owner.tx.SavePoint(name).Error; err != nil {
+		return err
+	}
+
+	if owner.queued == nil {
+		owner.queued = make(map[string]int)
+	}
+
+	owner.savePoint = name
+	owner.queued[name] = len(owner.afterCommit)
+
+	return nil
+}
+
+func (t *transaction) RollbackTo(name string) error {
+	owner := t.owner()
+	if !owner.IsActive() {
+		return ErrInvalidTransaction
+	}
+
+	if err := owner.tx.RollbackTo(name).Error; err != nil {
+		return err
+	}
+
+	owner.dropQueued(name)
+
+	return nil
+}
+
+// dropQueued drops the afterCommit notifications queued since the savepoint name.
+func (t *transaction) dropQueued(name string) {
+	if queued, ok := t.queued[name]; ok && queued < len(t.afterCommit) {
+		t.afterCommit = t.afterCommit[:queued]
+	}
+}
+
+// WithTransaction runs fn within a new transaction, which entities join with
+// SetTx(tx, false). It commits when fn returns nil and rolls back when fn fails
+// or panics, re-panicking afterwards.
+func WithTransaction(ctx context.Context, fn func(tx Transaction) error) (err error) {
+	if err := ready(); err != nil {
+		return err
+	}
+
+	t := &transaction{tx: begin(ctx)}
+	if t.tx.Error != nil {
+		return t.tx.Error
+	}
+
+	panicked := true
+
+	defer func() {
+		if !t.IsActive() {
+			return
+		}
+
+		if panicked || err != nil {
+			if rerr := t.Rollback(); rerr != nil {
+				err = errors.Join(err, rerr)
+			}
+		}
+	}()
+
+	err = fn(t)
+	panicked = false
+
+	if err != nil || !t.IsActive() {
+		return err
+	}
+
+	return t.Commit()
+}
+
+// owner returns the transaction that began the underlying tx, so entities
+// joined through SetTx share its state.
+func (t *transaction) owner() *transaction {
+	if t.root != nil {
+		return t.root
+	}
+
+	return t
+}
+
+// OrderColumn is one column of OrderByMany.
+type OrderColumn struct {
+	Name string
+	Desc bool
+}
+
+// CountSpec describes one labeled count computed by Counts. Where restricts the
+// counted rows and Distinct counts distinct values of that column, both optional.
+type CountSpec struct {
+	Label    string
+	Where    *Clause
+	Distinct string
 }
 
 type Entity[E entity] struct {
@@ -81,6 +378,26 @@ type Entity[E entity] struct {
 	table       E
 	clause      *Clause
 	hasMany     bool
+	limited     bool
+	ciphers     map[string]Cipher
+	selects     []clause.Expr
+	asOf        *time.Time
+	alias       string
+	cascades    []string
+	chunkTx     bool
+	grouped     bool
+	insertCols  []string
+	returning   []string
+	// buildErr records the first invalid builder call, every query run by the
+	// entity then fails with it without reaching the database.
+	buildErr error
+	// bound builds, by scope index, the scopes reading the entity so that Clone
+	// can rebuild them over the clone.
+	bound   map[int]func(*Entity[E]) func(*gorm.DB) *gorm.DB
+	timeout time.Duration
+	// wheres holds the scopes added by Where and OrWhere, also found in the
+	// transaction scopes, for lookups that only apply the conditions.
+	wheres []func(*gorm.DB) *gorm.DB
 }
 
 func SQL[E entity](ent E) Entitier[E] {
@@ -91,47 +408,249 @@ func SQL[E entity](ent E) Entitier[E] {
 	}
 }
 
+// Select selects cols, each a column optionally qualified by its table and
+// followed by an alias, or *. Anything else, such as an expression, fails the
+// query with ErrInvalidField, so cols may come from a request; use SelectExpr
+// for expressions.
 func (e *Entity[E]) Select(cols ...string) Entitier[E] {
-	e.transaction.scopes = append(
-		e.transaction.scopes,
-		func(db *gorm.DB) *gorm.DB {
-			return db.Select(cols)
-		},
-	)
+	for _, col := range cols {
+		if !columnRegexp.MatchString(col) {
+			e.fail(fmt.Errorf("%w: %q", ErrInvalidField, col))
+
+			continue
+		}
+
+		e.addSelect(clause.Expr{SQL: col})
+	}
 
 	return e
 }
 
+// SelectExpr selects the SQL expression expr binding args, such as
+// "COUNT(*) AS total". Unlike Select it is not validated and must not be built
+// from user input.
+func (e *Entity[E]) SelectExpr(expr string, args ...any) Entitier[E] {
+	e.addSelect(clause.Expr{SQL: expr, Vars: args})
+
+	return e
+}
+
+// SelectCoalesce selects COALESCE(column, defaultVal) AS alias, binding defaultVal
+// as an argument. It composes with the other Select methods.
+func (e *Entity[E]) SelectCoalesce(column string, defaultVal any, alias string) Entitier[E] {
+	e.addSelect(clause.Expr{
+		SQL:  "COALESCE(?, ?) AS ?",
+		Vars: []any{clause.Column{Name: column}, defaultVal, clause.Column{Name: alias}},
+	})
+
+	return e
+}
+
+// SelectStringAgg selects the values of column in each group joined by sep, using
+// string_agg on Postgres and GROUP_CONCAT on MySQL and SQLite. MySQL does not
+// bind a SEPARATOR, so there a sep holding a quote, backslash or "?" fails the
+// query with ErrInvalidValue.
+func (e *Entity[E]) SelectStringAgg(column, sep, alias string) Entitier[E] {
+	expr := clause.Expr{
+		SQL:  "string_agg(?, ?) AS ?",
+		Vars: []any{clause.Column{Name: column}, sep, clause.Column{Name: alias}},
+	}
+
+	switch dialect() {
+	case "mysql":
+		if strings.ContainsAny(sep, `'\?`) {
+			e.fail(ErrInvalidValue)
+
+			return e
+		}
+
+		expr.SQL = "GROUP_CONCAT(? SEPARATOR '" + sep + "') AS ?"
+		expr.Vars = []any{clause.Column{Name: column}, clause.Column{Name: alias}}
+	case "sqlite":
+		expr.SQL = "group_concat(?, ?) AS ?"
+	}
+
+	e.addSelect(expr)
+
+	return e
+}
+
+// SelectExists selects EXISTS(sub) AS alias, a per-row boolean flag when sub is
+// correlated to the outer table. Scan it with ScanInto into a struct with a bool field.
+func (e *Entity[E]) SelectExists(alias string, sub Subquery) Entitier[E] {
+	subquery, _, err := sub.subquery(context.Background())
+	if err != nil {
+		e.fail(err)
+
+		return e
+	}
+
+	e.addSelect(clause.Expr{
+		SQL:  "EXISTS(?) AS ?",
+		Vars: []any{subquery, clause.Column{Name: alias}},
+	})
+
+	return e
+}
+
+// addSelect accumulates select expressions so that successive Select calls
+// compose instead of replacing each other.
+func (e *Entity[E]) addSelect(expr clause.Expr) {
+	if len(e.selects) == 0 {
+		e.bindScope(func(e *Entity[E]) func(*gorm.DB) *gorm.DB {
+			return func(db *gorm.DB) *gorm.DB {
+				return applySelects(db, e.selects)
+			}
+		})
+	}
+
+	e.selects = append(e.selects, expr)
+}
+
 func (e *Entity[E]) Where(whereClause *Clause) Entitier[E] {
+	if whereClause == nil {
+		e.fail(ErrInvalidValue)
+
+		return e
+	}
+
+	e.fail(whereClause.err)
 	e.clause = whereClause
+	e.addWhere(func(db *gorm.DB) *gorm.DB {
+		if len(whereClause.builder) == 0 {
+			return db
+		}
+
+		args := whereClause.ToSQL()
+		if err := whereClause.check(args[1:]); err != nil {
+			_ = db.AddError(err)
+
+			return db
+		}
+
+		if len(args) > 1 {
+			return db.Where(args[0], args[1:]...)
+		}
+
+		return db.Where(args[0])
+	})
+
+	return e
+}
+
+// OrWhere adds whereClause as an alternative to the conditions applied before
+// it, parenthesizing both sides: Where(a).OrWhere(b) renders (a) OR (b). Later
+// conditions apply to the whole group, Where(c) then rendering ((a) OR (b)) AND c.
+func (e *Entity[E]) OrWhere(whereClause *Clause) Entitier[E] {
+	if whereClause == nil {
+		e.fail(ErrInvalidValue)
+
+		return e
+	}
+
+	e.fail(whereClause.err)
+	e.addWhere(func(db *gorm.DB) *gorm.DB {
+		if len(whereClause.builder) == 0 {
+			return db
+		}
+
+		args := whereClause.ToSQL()
+		if err := whereClause.check(args[1:]); err != nil {
+			_ = db.AddError(err)
+
+			return db
+		}
+
+		return db.Where(orCondition{clause.Expr{SQL: args[0].(string), Vars: args[1:]}})
+	})
+
+	return e
+}
+
+// WhereIf applies Where(whereClause) only when cond holds, for optional filters.
+func (e *Entity[E]) WhereIf(cond bool, whereClause *Clause) Entitier[E] {
+	if !cond {
+		return e
+	}
+
+	return e.Where(whereClause)
+}
+
+func (e *Entity[E]) OrderBy(name string, ascending bool) Entitier[E] {
 	e.transaction.scopes = append(
 		e.transaction.scopes,
 		func(db *gorm.DB) *gorm.DB {
-			args := whereClause.ToSQL()
-			if len(args) > 1 {
-				return db.Where(args[0], args[1:]...)
+			if ascending {
+				return db.Order(name + ASCOperator)
 			}
 
-			if len(args) > 0 {
-				return db.Where(args[0])
-			}
+			return db.Order(name + DESCOperator)
+		},
+	)
 
-			return nil
+	return e
+}
+
+// OrderByMany orders by cols, in priority order.
+func (e *Entity[E]) OrderByMany(cols ...OrderColumn) Entitier[E] {
+	for _, col := range cols {
+		e.OrderBy(col.Name, !col.Desc)
+	}
+
+	return e
+}
+
+// OrderByCI orders case-insensitively by column, rendered as ORDER BY LOWER(column).
+func (e *Entity[E]) OrderByCI(column string, desc bool) Entitier[E] {
+	e.transaction.scopes = append(
+		e.transaction.scopes,
+		func(db *gorm.DB) *gorm.DB {
+			return db.Order(clause.OrderByColumn{
+				Column: clause.Column{Name: "LOWER(" + db.Statement.Quote(column) + ")", Raw: true},
+				Desc:   desc,
+			})
 		},
 	)
 
 	return e
 }
 
-func (e *Entity[E]) OrderBy(name string, ascending bool) Entitier[E] {
+// After pages by keyset: it keeps the rows past cursorValue in cursorColumn and
+// orders by it, descending when desc. Combine it with Limit for the page size and
+// pass the cursor of the page's last row to fetch the next one.
+func (e *Entity[E]) After(cursorColumn string, cursorValue any, desc bool) Entitier[E] {
+	return e.AfterKeys([]string{cursorColumn}, []any{cursorValue}, desc)
+}
+
+// AfterKeys is like After over a composite cursor, compared as a row value so that
+// a unique trailing column, usually the primary key, breaks ties of the former.
+func (e *Entity[E]) AfterKeys(cursorColumns []string, cursorValues []any, desc bool) Entitier[E] {
+	if len(cursorColumns) == 0 || len(cursorColumns) != len(cursorValues) {
+		e.fail(ErrInvalidValue)
+
+		return e
+	}
+
 	e.transaction.scopes = append(
 		e.transaction.scopes,
 		func(db *gorm.DB) *gorm.DB {
-			if ascending {
-				return db.Order(name + " ASC ")
+			operator := GTOperator
+			if desc {
+				operator = LTOperator
 			}
 
-			return db.Order(name + " DESC ")
+			cols := make([]string, len(cursorColumns))
+			for i, col := range cursorColumns {
+				cols[i] = db.Statement.Quote(col)
+				db = db.Order(clause.OrderByColumn{Column: clause.Column{Name: col}, Desc: desc})
+			}
+
+			placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(cursorValues)), ", ")
+
+			return db.Where(
+				"("+strings.Join(cols, ", ")+") "+operator+" ("+placeholders+")",
+				cursorValues...,
+			)
 		},
 	)
 
@@ -150,6 +669,7 @@ func (e *Entity[E]) Offset(value int) Entitier[E] {
 }
 
 func (e *Entity[E]) Limit(value int) Entitier[E] {
+	e.limited = true
 	e.transaction.scopes = append(
 		e.transaction.scopes,
 		func(db *gorm.DB) *gorm.DB {
@@ -160,7 +680,15 @@ func (e *Entity[E]) Limit(value int) Entitier[E] {
 	return e
 }
 
+// NoLimit opts the query out of the default limit set by SetDefaultLimit.
+func (e *Entity[E]) NoLimit() Entitier[E] {
+	e.limited = true
+
+	return e
+}
+
 func (e *Entity[E]) GroupBy(name string) Entitier[E] {
+	e.grouped = true
 	e.transaction.scopes = append(
 		e.transaction.scopes,
 		func(db *gorm.DB) *gorm.DB {
@@ -171,24 +699,142 @@ func (e *Entity[E]) GroupBy(name string) Entitier[E] {
 	return e
 }
 
-func (e *Entity[E]) Having(whereClause *Clause) Entitier[E] {
-	e.clause = whereClause
+// Distinct selects distinct values of cols, or distinct rows when cols is empty.
+func (e *Entity[E]) Distinct(cols ...string) Entitier[E] {
 	e.transaction.scopes = append(
 		e.transaction.scopes,
 		func(db *gorm.DB) *gorm.DB {
-			return db.Having(e.clause.ToSQL())
+			args := make([]any, len(cols))
+			for i, col := range cols {
+				args[i] = col
+			}
+
+			return db.Distinct(args...)
 		},
 	)
 
 	return e
 }
 
+func (e *Entity[E]) Having(whereClause *Clause) Entitier[E] {
+	if whereClause == nil {
+		e.fail(ErrInvalidValue)
+
+		return e
+	}
+
+	e.fail(whereClause.err)
+	e.clause = whereClause
+	e.bindScope(func(e *Entity[E]) func(*gorm.DB) *gorm.DB {
+		return func(db *gorm.DB) *gorm.DB {
+			args := e.havingClause(whereClause).ToSQL()
+			if err := whereClause.check(args[1:]); err != nil {
+				_ = db.AddError(err)
+
+				return db
+			}
+
+			if len(args) > 1 {
+				return db.Having(args[0], args[1:]...)
+			}
+
+			if len(args) > 0 {
+				return db.Having(args[0])
+			}
+
+			return db
+		}
+	})
+
+	return e
+}
+
+// havingClause rewrites HAVING fields naming a select alias into the aliased
+// expression on Postgres, which does not resolve select aliases in HAVING.
+func (e *Entity[E]) havingClause(c *Clause) *Clause {
+	if dialect() != "postgres" {
+		return c
+	}
+
+	exprs := make(map[string]string)
+
+	for _, sel := range e.selects {
+		if len(sel.Vars) > 0 {
+			continue
+		}
+
+		if m := aliasRegexp.FindStringSubmatch(sel.SQL); m != nil {
+			exprs[m[2]] = m[1]
+		}
+	}
+
+	if len(exprs) == 0 {
+		return c
+	}
+
+	rewritten := &Clause{builder: make([]Builer, len(c.builder)), err: c.err, binds: c.binds}
+	copy(rewritten.builder, c.builder)
+
+	for i, b := range rewritten.builder {
+		key := strings.TrimPrefix(b.key, NOTOperator)
+		if expr, ok := exprs[key]; ok {
+			rewritten.builder[i].key = strings.TrimSuffix(b.key, key) + expr
+		}
+	}
+
+	return rewritten
+}
+
 func (e *Entity[E]) IsMany() Entitier[E] {
 	e.hasMany = true
 
 	return e
 }
 
+// Clone returns a copy of the query which can be extended without affecting e,
+// to branch a base query into several. Both still share the table value.
+func (e *Entity[E]) Clone() Entitier[E] {
+	c := *e
+
+	t := *e.transaction
+	t.scopes = append(make([]func(*gorm.DB) *gorm.DB, 0, len(e.transaction.scopes)), e.transaction.scopes...)
+	t.afterCommit = nil
+
+	if t.tx != nil {
+		t.root = e.transaction.owner()
+	}
+
+	c.transaction = &t
+	c.clause = &Clause{
+		builder: append([]Builer(nil), e.clause.builder...),
+		not:     e.clause.not,
+		err:     e.clause.err,
+	}
+
+	for name, value := range e.clause.binds {
+		c.clause.Bind(name, value)
+	}
+
+	c.selects = append([]clause.Expr(nil), e.selects...)
+	c.cascades = append([]string(nil), e.cascades...)
+	c.insertCols = append([]string(nil), e.insertCols...)
+	c.returning = append([]string(nil), e.returning...)
+	c.wheres = append([]func(*gorm.DB) *gorm.DB(nil), e.wheres...)
+
+	c.ciphers = make(map[string]Cipher, len(e.ciphers))
+	for column, cipher := range e.ciphers {
+		c.ciphers[column] = cipher
+	}
+
+	c.bound = make(map[int]func(*Entity[E]) func(*gorm.DB) *gorm.DB, len(e.bound))
+	for i, bind := range e.bound {
+		c.bound[i] = bind
+		t.scopes[i] = bind(&c)
+	}
+
+	return &c
+}
+
 func (e *Entity[E]) ToSQL() []any {
 	var table string
 
@@ -209,6 +855,21 @@ func (e *Entity[E]) ToSQL() []any {
 }
 
 func (e *Entity[E]) Join(arg any) Entitier[E] {
+	return e.join(arg, "")
+}
+
+// LeftJoin is like Join but always joins the association with LEFT JOIN, keeping
+// the rows without a match, instead of preloading it when no condition is given.
+func (e *Entity[E]) LeftJoin(arg any) Entitier[E] {
+	return e.join(arg, clause.LeftJoin)
+}
+
+// InnerJoin is like LeftJoin with INNER JOIN, dropping the rows without a match.
+func (e *Entity[E]) InnerJoin(arg any) Entitier[E] {
+	return e.join(arg, clause.InnerJoin)
+}
+
+func (e *Entity[E]) join(arg any, kind clause.JoinType) Entitier[E] {
 	var args []any
 
 	if _, ok := arg.(*Clause); ok {
@@ -246,6 +907,10 @@ func (e *Entity[E]) Join(arg any) Entitier[E] {
 		e.transaction.scopes = append(
 			e.transaction.scopes,
 			func(db *gorm.DB) *gorm.DB {
+				if kind == clause.InnerJoin {
+					return db.InnerJoins(table, db.Where(stmt, args[2:])) //nolint
+				}
+
 				return db.Joins(table, db.Where(stmt, args[2:])) //nolint
 			},
 		)
@@ -253,6 +918,13 @@ func (e *Entity[E]) Join(arg any) Entitier[E] {
 		e.transaction.scopes = append(
 			e.transaction.scopes,
 			func(db *gorm.DB) *gorm.DB {
+				switch kind {
+				case clause.InnerJoin:
+					return db.InnerJoins(table)
+				case clause.LeftJoin:
+					return db.Joins(table)
+				}
+
 				return db.Preload(table)
 			},
 		)
@@ -261,185 +933,1415 @@ func (e *Entity[E]) Join(arg any) Entitier[E] {
 	return e
 }
 
-func (e *Entity[E]) Find(ctx context.Context) ([]E, error) {
+// Preload eager-loads an association with a separate query per association,
+// which suits has-many ones. conds, all of which must hold, filter the loaded rows.
+func (e *Entity[E]) Preload(assoc string, conds ...*Clause) Entitier[E] {
+	e.transaction.scopes = append(
+		e.transaction.scopes,
+		func(db *gorm.DB) *gorm.DB {
+			cond := AllOf(conds...)
+			if len(cond.builder) == 0 {
+				return db.Preload(assoc)
+			}
+
+			args := cond.ToSQL()
+			if err := cond.check(args[1:]); err != nil {
+				_ = db.AddError(err)
+
+				return db
+			}
+
+			return db.Preload(assoc, args...)
+		},
+	)
+
+	return e
+}
+
+// JoinPreload eager-loads a belongs-to/has-one association in the same query
+// using a SQL JOIN instead of Preload's extra query. conds filter the joined rows.
+func (e *Entity[E]) JoinPreload(assoc string, conds ...*Clause) Entitier[E] {
+	for _, cond := range conds {
+		if cond == nil {
+			e.fail(ErrInvalidValue)
+
+			return e
+		}
+
+		e.fail(cond.err)
+	}
+
+	e.transaction.scopes = append(
+		e.transaction.scopes,
+		func(db *gorm.DB) *gorm.DB {
+			if len(conds) == 0 {
+				return db.Joins(assoc)
+			}
+
+			where := db.Session(&gorm.Session{NewDB: true})
+			for _, cond := range conds {
+				args := cond.ToSQL()
+				if err := cond.check(args[1:]); err != nil {
+					_ = db.AddError(err)
+
+					return db
+				}
+
+				where = where.Where(args[0], args[1:]...)
+			}
+
+			return db.Joins(assoc, where)
+		},
+	)
+
+	return e
+}
+
+// ReadFresh routes the read to the primary even when replicas are registered,
+// for reads that must observe a preceding write.
+func (e *Entity[E]) ReadFresh() Entitier[E] {
+	e.transaction.scopes = append(
+		e.transaction.scopes,
+		func(db *gorm.DB) *gorm.DB {
+			return db.Clauses(dbresolver.Write)
+		},
+	)
+
+	return e
+}
+
+// Timeout bounds each query run by the entity to d, on top of the deadline of the
+// caller's context. Paginate and FindInBatches bound each of their statements,
+// not the whole call. It does not apply to Rows and the Tx variants, whose result
+// outlives the call.
+func (e *Entity[E]) Timeout(d time.Duration) Entitier[E] {
+	e.timeout = d
+
+	return e
+}
+
+// Unscoped includes soft-deleted rows in reads, and makes Update and Delete
+// reach them too, Delete then removing the rows permanently.
+func (e *Entity[E]) Unscoped() Entitier[E] {
+	e.transaction.scopes = append(
+		e.transaction.scopes,
+		func(db *gorm.DB) *gorm.DB {
+			return db.Unscoped()
+		},
+	)
+
+	return e
+}
+
+// AsOf queries a system-versioned table as it was at t, rendering
+// FOR SYSTEM_TIME AS OF. It is only supported by MariaDB, through the mysql
+// dialect, the query failing with ErrUnsupportedDriver elsewhere, MySQL included.
+func (e *Entity[E]) AsOf(t time.Time) Entitier[E] {
+	e.asOf = &t
+	e.bindScope(func(e *Entity[E]) func(*gorm.DB) *gorm.DB {
+		return func(db *gorm.DB) *gorm.DB {
+			if dialect() != "mysql" || !mariaDB() {
+				_ = db.AddError(ErrUnsupportedDriver)
+
+				return db
+			}
+
+			return e.from(db)
+		}
+	})
+
+	return e
+}
+
+// As aliases the entity's table, FROM users AS u, so clause fields can be
+// qualified with the alias as in EQ("u.id", 1).
+func (e *Entity[E]) As(alias string) Entitier[E] {
+	e.alias = alias
+	e.bindScope(func(e *Entity[E]) func(*gorm.DB) *gorm.DB {
+		return func(db *gorm.DB) *gorm.DB {
+			return e.from(db)
+		}
+	})
+
+	return e
+}
+
+// from renders the FROM table with the AsOf and As modifiers.
+func (e *Entity[E]) from(db *gorm.DB) *gorm.DB {
+	table := db.Statement.Quote(e.table.TableName())
+	vars := make([]any, 0, 1)
+
+	if e.asOf != nil {
+		table += " FOR SYSTEM_TIME AS OF ?"
+		vars = append(vars, *e.asOf)
+	}
+
+	if len(e.alias) > 0 {
+		// Left unquoted so gorm picks the alias up as the statement's table.
+		table += " AS " + e.alias
+	}
+
+	return db.Table(table, vars...)
+}
+
+// Lock adds a locking clause such as FOR UPDATE (strength "UPDATE") to the read.
+// Locks are only meaningful inside a transaction, so reads without one set by
+// SetTx fail with ErrLockRequiresTx.
+func (e *Entity[E]) Lock(strength string) Entitier[E] {
+	return e.lock(func(*gorm.DB) clause.Locking {
+		return clause.Locking{Strength: strength}
+	})
+}
+
+// ForUpdate locks the read rows against writes and other locks, it is Lock("UPDATE").
+func (e *Entity[E]) ForUpdate() Entitier[E] {
+	return e.Lock("UPDATE")
+}
+
+// ForShare locks the read rows against writes only, it is Lock("SHARE").
+func (e *Entity[E]) ForShare() Entitier[E] {
+	return e.Lock("SHARE")
+}
+
+// LockOf adds FOR UPDATE OF tables, locking only the rows of those tables of a
+// join. Like Lock, it requires a transaction.
+func (e *Entity[E]) LockOf(tables ...string) Entitier[E] {
+	return e.lock(func(db *gorm.DB) clause.Locking {
+		quoted := make([]string, 0, len(tables))
+		for _, table := range tables {
+			quoted = append(quoted, db.Statement.Quote(table))
+		}
+
+		return clause.Locking{
+			Strength: "UPDATE",
+			Table:    clause.Table{Name: strings.Join(quoted, ", "), Raw: true},
+		}
+	})
+}
+
+func (e *Entity[E]) lock(locking func(*gorm.DB) clause.Locking) Entitier[E] {
+	e.bindScope(func(e *Entity[E]) func(*gorm.DB) *gorm.DB {
+		return func(db *gorm.DB) *gorm.DB {
+			if e.transaction.tx == nil {
+				_ = db.AddError(ErrLockRequiresTx)
+
+				return db
+			}
+
+			return db.Clauses(locking(db))
+		}
+	})
+
+	return e
+}
+
+func (e *Entity[E]) Find(ctx context.Context) ([]E, error) {
+	result := make([]E, 0)
+
+	err := e.read(ctx, func(tx *gorm.DB) error {
+		return e.findQuery(tx).Find(&result).Error
+	})
+	if err != nil {
+		return nil, e.joinError(err)
+	}
+
+	if err := e.decryptFields(ctx, result); err != nil {
+		return nil, e.joinError(err)
+	}
+
+	return result, nil
+}
+
+// findQuery applies the scopes of the query and the default limit to tx.
+func (e *Entity[E]) findQuery(tx *gorm.DB) *gorm.DB {
+	query := tx.Scopes(e.transaction.scopes...)
+	if defaultLimit > 0 && !e.limited {
+		query = query.Limit(defaultLimit)
+	}
+
+	return query
+}
+
+// ByIDsUnique fetches rows by primary key, dropping duplicated ids before querying
+// so each id yields at most one row. ids must hold comparable values.
+func (e *Entity[E]) ByIDsUnique(ctx context.Context, ids []any) ([]E, error) {
+	result := make([]E, 0)
+
+	seen := make(map[any]struct{}, len(ids))
+	unique := make([]any, 0, len(ids))
+
+	for _, id := range ids {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+
+		seen[id] = struct{}{}
+		unique = append(unique, id)
+	}
+
+	if len(unique) == 0 {
+		return result, nil
+	}
+
+	err := e.read(ctx, func(tx *gorm.DB) error {
+		return tx.Scopes(e.transaction.scopes...).Find(&result, unique).Error
+	})
+	if err != nil {
+		return nil, e.joinError(err)
+	}
+
+	if err := e.decryptFields(ctx, result); err != nil {
+		return nil, e.joinError(err)
+	}
+
+	return result, nil
+}
+
+// ModifiedSince returns the rows whose column is after since, ordered by column
+// then primary key so incremental pulls page through ties stably.
+func (e *Entity[E]) ModifiedSince(ctx context.Context, column string, since time.Time) ([]E, error) {
+	result := make([]E, 0)
+
+	pk, err := primaryKey(e.table)
+	if err != nil {
+		return nil, e.joinError(err)
+	}
+
+	err = e.read(ctx, func(tx *gorm.DB) error {
+		return tx.Scopes(e.transaction.scopes...).
+			Where(clause.Gt{Column: clause.Column{Name: column}, Value: since}).
+			Order(clause.OrderByColumn{Column: clause.Column{Name: column}}).
+			Order(clause.OrderByColumn{Column: clause.Column{Name: pk}}).
+			Find(&result).Error
+	})
+	if err != nil {
+		return nil, e.joinError(err)
+	}
+
+	if err := e.decryptFields(ctx, result); err != nil {
+		return nil, e.joinError(err)
+	}
+
+	return result, nil
+}
+
+// TopNPerGroup returns at most n rows per distinct partitionCol value, the first
+// ones by orderCol, ranked with ROW_NUMBER() OVER (PARTITION BY ... ORDER BY ...).
+func (e *Entity[E]) TopNPerGroup(
+	ctx context.Context,
+	partitionCol, orderCol string,
+	n int,
+	desc bool,
+) ([]E, error) {
 	result := make([]E, 0)
 
-	err := db.WithContext(ctx).Scopes(e.transaction.scopes...).Find(&result).Error
+	direction := "ASC"
+	if desc {
+		direction = "DESC"
+	}
+
+	err := e.read(ctx, func(tx *gorm.DB) error {
+		ranked := tx.Session(&gorm.Session{NewDB: true}).
+			Model(e.table).
+			Scopes(e.transaction.scopes...).
+			Select(
+				"?.*, ROW_NUMBER() OVER (PARTITION BY ? ORDER BY ? "+direction+") AS entigorm_rn",
+				clause.Table{Name: e.table.TableName()},
+				clause.Column{Name: partitionCol},
+				clause.Column{Name: orderCol},
+			)
+
+		return tx.Table("(?) AS ranked", ranked).
+			Where("entigorm_rn <= ?", n).
+			Order(clause.OrderByColumn{Column: clause.Column{Name: partitionCol}}).
+			Order("entigorm_rn").
+			Find(&result).Error
+	})
 	if err != nil {
 		return nil, e.joinError(err)
 	}
 
-	return result, err
+	if err := e.decryptFields(ctx, result); err != nil {
+		return nil, e.joinError(err)
+	}
+
+	return result, nil
+}
+
+func (e *Entity[E]) One(ctx context.Context) (E, error) {
+	var result E
+
+	err := e.read(ctx, func(tx *gorm.DB) error {
+		return tx.Scopes(e.transaction.scopes...).First(&result).Error
+	})
+	if err != nil {
+		return result, e.joinError(err)
+	}
+
+	if err := e.decryptFields(ctx, &result); err != nil {
+		return result, e.joinError(err)
+	}
+
+	return result, nil
+}
+
+// Last returns the matching row with the highest primary key.
+func (e *Entity[E]) Last(ctx context.Context) (E, error) {
+	var result E
+
+	err := e.read(ctx, func(tx *gorm.DB) error {
+		return tx.Scopes(e.transaction.scopes...).Last(&result).Error
+	})
+	if err != nil {
+		return result, e.joinError(err)
+	}
+
+	if err := e.decryptFields(ctx, &result); err != nil {
+		return result, e.joinError(err)
+	}
+
+	return result, nil
+}
+
+func (e *Entity[E]) Count(ctx context.Context) (int64, error) {
+	var count int64
+
+	err := e.read(ctx, func(tx *gorm.DB) error {
+		return tx.Model(e.table).Scopes(e.transaction.scopes...).Count(&count).Error
+	})
+	if err != nil {
+		return -1, e.joinError(err)
+	}
+
+	return count, nil
+}
+
+// Sum returns the sum of column over the matching rows, 0 when none match.
+func (e *Entity[E]) Sum(ctx context.Context, column string) (float64, error) {
+	return e.aggregate(ctx, "SUM", column)
+}
+
+// Avg returns the average of column over the matching rows, 0 when none match.
+func (e *Entity[E]) Avg(ctx context.Context, column string) (float64, error) {
+	return e.aggregate(ctx, "AVG", column)
+}
+
+// Max returns the greatest value of column over the matching rows, 0 when none match.
+func (e *Entity[E]) Max(ctx context.Context, column string) (float64, error) {
+	return e.aggregate(ctx, "MAX", column)
+}
+
+// Min returns the smallest value of column over the matching rows, 0 when none match.
+func (e *Entity[E]) Min(ctx context.Context, column string) (float64, error) {
+	return e.aggregate(ctx, "MIN", column)
+}
+
+func (e *Entity[E]) aggregate(ctx context.Context, fn, column string) (float64, error) {
+	var value sql.NullFloat64
+
+	err := e.read(ctx, func(tx *gorm.DB) error {
+		return tx.Model(e.table).
+			Scopes(e.transaction.scopes...).
+			Select(fn+"(?)", clause.Column{Name: column}).
+			Scan(&value).Error
+	})
+	if err != nil {
+		return 0, e.joinError(err)
+	}
+
+	return value.Float64, nil
+}
+
+// Explain returns the database plan of the query Find would run.
+func (e *Entity[E]) Explain(ctx context.Context) (string, error) {
+	return e.explain(ctx, "EXPLAIN ")
+}
+
+// ExplainAnalyze is like Explain but executes the query to report actual timings.
+func (e *Entity[E]) ExplainAnalyze(ctx context.Context) (string, error) {
+	return e.explain(ctx, "EXPLAIN ANALYZE ")
+}
+
+// ExplainSQL renders the statement Find would run, arguments inlined, to log or
+// debug it. It returns "" when the entity cannot run queries.
+func (e *Entity[E]) ExplainSQL(ctx context.Context) string {
+	if err := e.ready(); err != nil {
+		return ""
+	}
+
+	result := make([]E, 0)
+
+	return e.session(ctx).ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return e.findQuery(tx).Find(&result)
+	})
+}
+
+func (e *Entity[E]) explain(ctx context.Context, prefix string) (string, error) {
+	if err := e.ready(); err != nil {
+		return "", e.joinError(err)
+	}
+
+	ctx, cancel := e.withTimeout(ctx)
+	defer cancel()
+
+	result := make([]E, 0)
+
+	stmt := e.findQuery(e.session(ctx).Session(&gorm.Session{DryRun: true})).Find(&result).Statement
+	if stmt.Error != nil {
+		return "", e.joinError(stmt.Error)
+	}
+
+	rows, err := e.session(ctx).Raw(prefix+stmt.SQL.String(), stmt.Vars...).Rows()
+	if err != nil {
+		return "", e.joinError(err)
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return "", e.joinError(err)
+	}
+
+	var plan []string
+
+	for rows.Next() {
+		values := make([]sql.NullString, len(cols))
+		dest := make([]any, len(cols))
+
+		for i := range values {
+			dest[i] = &values[i]
+		}
+
+		if err := rows.Scan(dest...); err != nil {
+			return "", e.joinError(err)
+		}
+
+		line := make([]string, len(values))
+		for i, v := range values {
+			line[i] = v.String
+		}
+
+		plan = append(plan, strings.Join(line, "\t"))
+	}
+
+	if err := rows.Err(); err != nil {
+		return "", e.joinError(err)
+	}
+
+	return strings.Join(plan, "\n"), nil
+}
+
+// Exists reports whether any row matches the query without counting them all.
+func (e *Entity[E]) Exists(ctx context.Context) (bool, error) {
+	return e.exists(ctx)
+}
+
+// ExistsBy reports whether a row with column equal to value matches the query,
+// typically to check uniqueness before an insert.
+func (e *Entity[E]) ExistsBy(ctx context.Context, column string, value any) (bool, error) {
+	return e.exists(ctx, func(db *gorm.DB) *gorm.DB {
+		return db.Where(clause.Eq{Column: clause.Column{Name: column}, Value: value})
+	})
+}
+
+// exists runs SELECT 1 ... LIMIT 1 over the query narrowed by scopes.
+func (e *Entity[E]) exists(ctx context.Context, scopes ...func(*gorm.DB) *gorm.DB) (bool, error) {
+	var ones []int
+
+	err := e.read(ctx, func(tx *gorm.DB) error {
+		return tx.Model(e.table).
+			Scopes(e.transaction.scopes...).
+			Scopes(scopes...).
+			Select("1").
+			Limit(1).
+			Scan(&ones).Error
+	})
+	if err != nil {
+		return false, e.joinError(err)
+	}
+
+	return len(ones) > 0, nil
+}
+
+// MaxPageSize is the largest page size Paginate serves.
+const MaxPageSize = 1000
+
+// Paginate returns the page-th page of pageSize rows along with the total number
+// of rows. With GroupBy the groups themselves are paginated and counted. page is
+// raised to 1 and pageSize kept within 1 and MaxPageSize. Limit and Offset of the
+// query are ignored, by the count too.
+func (e *Entity[E]) Paginate(ctx context.Context, page, pageSize int) (items []E, total int64, err error) {
+	if err := e.ready(); err != nil {
+		return nil, 0, e.joinError(err)
+	}
+
+	if page < 1 {
+		page = 1
+	}
+
+	if pageSize < 1 {
+		pageSize = 1
+	} else if pageSize > MaxPageSize {
+		pageSize = MaxPageSize
+	}
+
+	unpaged := func(db *gorm.DB) *gorm.DB {
+		return db.Limit(-1).Offset(-1)
+	}
+
+	countCtx, cancelCount := e.withTimeout(ctx)
+	defer cancelCount()
+
+	if e.grouped {
+		sub := e.session(countCtx).Model(e.table).Scopes(e.transaction.scopes...).Scopes(unpaged)
+		err = e.session(countCtx).Table("(?) AS grouped", sub).Count(&total).Error
+	} else {
+		err = e.session(countCtx).Model(e.table).Scopes(e.transaction.scopes...).Scopes(unpaged).Count(&total).Error
+	}
+
+	if err != nil {
+		return nil, 0, e.joinError(err)
+	}
+
+	items = make([]E, 0)
+
+	findCtx, cancelFind := e.withTimeout(ctx)
+	defer cancelFind()
+
+	err = e.session(findCtx).
+		Scopes(e.transaction.scopes...).
+		Scopes(func(db *gorm.DB) *gorm.DB {
+			return unpaged(db).Offset((page - 1) * pageSize).Limit(pageSize)
+		}).
+		Find(&items).Error
+	if err != nil {
+		return nil, 0, e.joinError(err)
+	}
+
+	if err := e.decryptFields(ctx, items); err != nil {
+		return nil, 0, e.joinError(err)
+	}
+
+	return items, total, nil
+}
+
+// Rows runs the query and returns the driver rows for incremental scanning.
+// The caller must Close the rows to release the connection.
+func (e *Entity[E]) Rows(ctx context.Context) (*sql.Rows, error) {
+	if err := e.ready(); err != nil {
+		return nil, e.joinError(err)
+	}
+
+	rows, err := e.session(ctx).Model(e.table).Scopes(e.transaction.scopes...).Rows()
+	if err != nil {
+		return nil, e.joinError(err)
+	}
+
+	return rows, nil
+}
+
+// FindInBatches runs the query batchSize rows at a time, in primary key order,
+// passing each batch to fn and stopping at the first error fn returns. It fails
+// with ErrInvalidValue when batchSize is not positive.
+func (e *Entity[E]) FindInBatches(ctx context.Context, batchSize int, fn func(batch []E) error) error {
+	if batchSize <= 0 {
+		return e.joinError(ErrInvalidValue)
+	}
+
+	if err := e.ready(); err != nil {
+		return e.joinError(err)
+	}
+
+	field, err := primaryField(e.table)
+	if err != nil {
+		return e.joinError(err)
+	}
+
+	var after any
+
+	for {
+		batch := make([]E, 0, batchSize)
+
+		if err := e.findBatch(ctx, after, batchSize, &batch); err != nil {
+			return e.joinError(err)
+		}
+
+		if len(batch) == 0 {
+			return nil
+		}
+
+		if err := e.decryptFields(ctx, batch); err != nil {
+			return e.joinError(err)
+		}
+
+		if err := fn(batch); err != nil {
+			return e.joinError(err)
+		}
+
+		if len(batch) < batchSize {
+			return nil
+		}
+
+		after, _ = field.ValueOf(ctx, reflect.Indirect(reflect.ValueOf(batch[len(batch)-1])))
+	}
+}
+
+// findBatch loads into batch the batchSize rows whose primary key follows after,
+// or the first ones when after is nil. Each batch gets its own Timeout, the time
+// spent in the callback of FindInBatches is not counted.
+func (e *Entity[E]) findBatch(ctx context.Context, after any, batchSize int, batch *[]E) error {
+	ctx, cancel := e.withTimeout(ctx)
+	defer cancel()
+
+	pk := clause.Column{Table: clause.CurrentTable, Name: clause.PrimaryKey}
+
+	tx := e.session(ctx).Order(clause.OrderByColumn{Column: pk})
+	if after != nil {
+		tx = tx.Where(clause.Gt{Column: pk, Value: after})
+	}
+
+	return tx.
+		Scopes(e.transaction.scopes...).
+		Scopes(func(db *gorm.DB) *gorm.DB {
+			return db.Limit(batchSize)
+		}).
+		Find(batch).Error
+}
+
+// ScanInto runs the query and scans the result into dest, a pointer to a struct
+// or slice of structs that need not be the entity, e.g. to read computed columns.
+func (e *Entity[E]) ScanInto(ctx context.Context, dest any) error {
+	err := e.read(ctx, func(tx *gorm.DB) error {
+		return tx.Model(e.table).Scopes(e.transaction.scopes...).Scan(dest).Error
+	})
+	if err != nil {
+		return e.joinError(err)
+	}
+
+	return nil
+}
+
+// Counts computes several labeled counts over the current query in a single
+// statement using conditional aggregates.
+func (e *Entity[E]) Counts(ctx context.Context, specs ...CountSpec) (map[string]int64, error) {
+	if err := e.ready(); err != nil {
+		return nil, e.joinError(err)
+	}
+
+	ctx, cancel := e.withTimeout(ctx)
+	defer cancel()
+
+	result := make(map[string]int64, len(specs))
+	if len(specs) == 0 {
+		return result, nil
+	}
+
+	exprs := make([]string, 0, len(specs))
+	vars := make([]any, 0)
+
+	for _, spec := range specs {
+		counted := "1"
+		if len(spec.Distinct) > 0 {
+			counted = "?"
+		}
+
+		expr := "COUNT(" + counted + ")"
+
+		if spec.Where != nil {
+			args := spec.Where.ToSQL()
+			if err := spec.Where.check(args[1:]); err != nil {
+				return nil, e.joinError(err)
+			}
+
+			if len(spec.Where.builder) > 0 {
+				expr = "COUNT(CASE WHEN " + args[0].(string) + " THEN " + counted + " END)"
+				vars = append(vars, args[1:]...)
+			}
+		}
+
+		if len(spec.Distinct) > 0 {
+			expr = strings.Replace(expr, "COUNT(", "COUNT(DISTINCT ", 1)
+			vars = append(vars, clause.Column{Name: spec.Distinct})
+		}
+
+		exprs = append(exprs, expr)
+	}
+
+	counts := make([]int64, len(specs))
+	dest := make([]any, len(specs))
+
+	for i := range counts {
+		dest[i] = &counts[i]
+	}
+
+	err := e.session(ctx).
+		Model(e.table).
+		Scopes(e.transaction.scopes...).
+		Select(strings.Join(exprs, ", "), vars...).
+		Row().
+		Scan(dest...)
+	if err != nil {
+		return nil, e.joinError(err)
+	}
+
+	for i, spec := range specs {
+		result[spec.Label] = counts[i]
+	}
+
+	return result, nil
+}
+
+func (e *Entity[E]) Insert(ctx context.Context) error {
+	if err := e.encryptFields(ctx, e.table); err != nil {
+		return e.joinError(err)
+	}
+	defer e.decryptFields(ctx, e.table) //nolint:errcheck
+
+	_, err := e.exec(ctx, "insert", func(tx *gorm.DB) *gorm.DB {
+		return tx.Scopes(e.insertSelect, e.returningClause).Create(e.table)
+	})
+
+	return err
+}
+
+// FirstOrCreate loads into e.table the first row, by primary key, matching the
+// Where conditions of the query or, when there is none, inserts e.table, and
+// returns it either way. The lookup reads the primary and ignores the other
+// scopes, such as Select; only an insert is reported to the OnWrite hooks.
+// A query without Where fails with ErrMissingWhereClause.
+func (e *Entity[E]) FirstOrCreate(ctx context.Context) (E, error) {
+	if len(e.wheres) == 0 {
+		return e.table, e.joinError(ErrMissingWhereClause)
+	}
+
+	if err := e.encryptFields(ctx, e.table); err != nil {
+		return e.table, e.joinError(err)
+	}
+	defer e.decryptFields(ctx, e.table) //nolint:errcheck
+
+	var found bool
+
+	err := e.read(ctx, func(tx *gorm.DB) error {
+		result := tx.Clauses(dbresolver.Write).
+			Scopes(e.wheres...).
+			Order(clause.OrderByColumn{Column: clause.Column{Table: clause.CurrentTable, Name: clause.PrimaryKey}}).
+			Limit(1).
+			Find(e.table)
+		found = result.RowsAffected > 0
+
+		return result.Error
+	})
+	if err != nil {
+		return e.table, e.joinError(err)
+	}
+
+	if found {
+		if _, err := e.commit(); err != nil {
+			return e.table, e.joinError(err)
+		}
+
+		return e.table, nil
+	}
+
+	_, err = e.exec(ctx, "insert", func(tx *gorm.DB) *gorm.DB {
+		return tx.Scopes(e.insertSelect, e.returningClause).Create(e.table)
+	})
+
+	return e.table, err
+}
+
+// InsertColumns restricts inserts to cols, leaving the other columns to their
+// database defaults.
+func (e *Entity[E]) InsertColumns(cols ...string) Entitier[E] {
+	e.insertCols = append(e.insertCols, cols...)
+
+	return e
+}
+
+func (e *Entity[E]) insertSelect(db *gorm.DB) *gorm.DB {
+	if len(e.insertCols) == 0 {
+		return db
+	}
+
+	return db.Select(e.insertCols)
+}
+
+// Returning reads cols back into e.table from the rows written by Insert, Update
+// and Delete and their Tx variants. It needs RETURNING support in the database:
+// Postgres and SQLite 3.35+ have it, MySQL ignores it.
+func (e *Entity[E]) Returning(cols ...string) Entitier[E] {
+	e.returning = append(e.returning, cols...)
+
+	return e
+}
+
+func (e *Entity[E]) returningClause(db *gorm.DB) *gorm.DB {
+	if len(e.returning) == 0 {
+		return db
+	}
+
+	returning := clause.Returning{Columns: make([]clause.Column, 0, len(e.returning))}
+	for _, col := range e.returning {
+		returning.Columns = append(returning.Columns, clause.Column{Name: col})
+	}
+
+	return db.Clauses(returning)
+}
+
+func (e *Entity[E]) InsertBatch(ctx context.Context, entities []E) error {
+	if err := e.encryptFields(ctx, entities); err != nil {
+		return e.joinError(err)
+	}
+	defer e.decryptFields(ctx, entities) //nolint:errcheck
+
+	_, err := e.exec(ctx, "insert", func(tx *gorm.DB) *gorm.DB {
+		return tx.Scopes(e.insertSelect).CreateInBatches(entities, len(entities))
+	})
+
+	return err
+}
+
+// InsertBatchReturning inserts entities and returns their generated primary keys
+// in input order, using RETURNING on Postgres and the backfilled fields elsewhere.
+func (e *Entity[E]) InsertBatchReturning(ctx context.Context, entities []E) ([]any, error) {
+	field, err := primaryField(e.table)
+	if err != nil {
+		return nil, e.joinError(err)
+	}
+
+	if err := e.encryptFields(ctx, entities); err != nil {
+		return nil, e.joinError(err)
+	}
+	defer e.decryptFields(ctx, entities) //nolint:errcheck
+
+	_, err = e.exec(ctx, "insert", func(tx *gorm.DB) *gorm.DB {
+		if dialect() == "postgres" {
+			tx = tx.Clauses(clause.Returning{Columns: []clause.Column{{Name: field.DBName}}})
+		}
+
+		return tx.Scopes(e.insertSelect).CreateInBatches(entities, len(entities))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]any, 0, len(entities))
+	for _, ent := range entities {
+		id, _ := field.ValueOf(ctx, reflect.Indirect(reflect.ValueOf(ent)))
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+func (e *Entity[E]) InsertTx(ctx context.Context) (tx Transaction, err error) {
+	if err := e.ready(); err != nil {
+		return nil, e.joinError(err)
+	}
+
+	if err := e.encryptFields(ctx, e.table); err != nil {
+		return nil, e.joinError(err)
+	}
+	defer e.decryptFields(ctx, e.table) //nolint:errcheck
+
+	e.transaction.tx = begin(ctx)
+	if err := e.transaction.tx.Error; err != nil {
+		e.transaction.tx = nil
+
+		return nil, e.joinError(err)
+	}
+
+	result := e.transaction.tx.Scopes(e.insertSelect, e.returningClause).Create(e.table)
+	if result.Error != nil {
+		return e.rollback(result.Error)
+	}
+
+	e.transaction.rowsAffected = result.RowsAffected
+	e.afterWrite(ctx, "insert", result.RowsAffected)
+
+	return e.commit()
+}
+
+// Upsert inserts e.table or, on conflict over conflictColumns, updates updateColumns.
+// With no updateColumns a conflicting row is left untouched, as DO NOTHING.
+// Optional guard clauses are rendered as `DO UPDATE ... WHERE` so the update only
+// happens when they hold, e.g. GT("excluded.updated_at", gorm.Expr("users.updated_at")).
+// A guard without updateColumns or a nil guard fails with ErrInvalidValue. Guards
+// are supported on Postgres and SQLite only, MySQL ignoring them in ON DUPLICATE
+// KEY UPDATE, and fail with ErrUnsupportedDriver elsewhere.
+func (e *Entity[E]) Upsert(ctx context.Context, conflictColumns, updateColumns []string, guard ...*Clause) error {
+	onConflict, err := onConflictClause(conflictColumns, updateColumns, guard)
+	if err != nil {
+		return e.joinError(err)
+	}
+
+	if err := e.encryptFields(ctx, e.table); err != nil {
+		return e.joinError(err)
+	}
+	defer e.decryptFields(ctx, e.table) //nolint:errcheck
+
+	_, err = e.exec(ctx, "upsert", func(tx *gorm.DB) *gorm.DB {
+		return tx.Clauses(onConflict).Create(e.table)
+	})
+
+	return err
+}
+
+// UpsertBatch upserts entities chunkSize rows per statement to stay under the
+// driver's parameter limits. All chunks share one transaction, the one set by
+// SetTx if any, unless PerChunkTx was called.
+func (e *Entity[E]) UpsertBatch(
+	ctx context.Context,
+	entities []E,
+	conflictColumns, updateColumns []string,
+	chunkSize int,
+) error {
+	if err := e.ready(); err != nil {
+		return e.joinError(err)
+	}
+
+	if len(entities) == 0 {
+		return nil
+	}
+
+	if chunkSize <= 0 {
+		chunkSize = len(entities)
+	}
+
+	onConflict, err := onConflictClause(conflictColumns, updateColumns, nil)
+	if err != nil {
+		return e.joinError(err)
+	}
+
+	if err := e.encryptFields(ctx, entities); err != nil {
+		return e.joinError(err)
+	}
+	defer e.decryptFields(ctx, entities) //nolint:errcheck
+
+	if e.transaction.tx != nil {
+		_, err := e.exec(ctx, "upsert", func(tx *gorm.DB) *gorm.DB {
+			return tx.Clauses(onConflict).CreateInBatches(entities, chunkSize)
+		})
+
+		return err
+	}
+
+	chunks := [][]E{entities}
+
+	if e.chunkTx {
+		chunks = make([][]E, 0, len(entities)/chunkSize+1)
+		for i := 0; i < len(entities); i += chunkSize {
+			end := i + chunkSize
+			if end > len(entities) {
+				end = len(entities)
+			}
+
+			chunks = append(chunks, entities[i:end])
+		}
+	}
+
+	for _, chunk := range chunks {
+		tx := begin(ctx)
+		if tx.Error != nil {
+			return e.joinError(tx.Error)
+		}
+
+		result := tx.Clauses(onConflict).CreateInBatches(chunk, chunkSize)
+		if result.Error != nil {
+			return e.joinError(errors.Join(result.Error, tx.Rollback().Error))
+		}
+
+		if err := tx.Commit().Error; err != nil {
+			return e.joinError(err)
+		}
+
+		e.afterWrite(ctx, "upsert", result.RowsAffected)
+	}
+
+	return nil
+}
+
+// PerChunkTx makes UpsertBatch commit each chunk in its own transaction, so
+// chunks written before a failure are kept.
+func (e *Entity[E]) PerChunkTx() Entitier[E] {
+	e.chunkTx = true
+
+	return e
+}
+
+// Update writes the non-zero fields of e.table to the rows matching the query;
+// fields set to false, 0 or "" are left untouched, use Save to write them.
+func (e *Entity[E]) Update(ctx context.Context) error {
+	_, err := e.UpdateResult(ctx)
+
+	return err
+}
+
+// UpdateResult is like Update but also returns the number of rows changed, 0
+// telling that no row matched.
+func (e *Entity[E]) UpdateResult(ctx context.Context) (int64, error) {
+	if err := e.encryptFields(ctx, e.table); err != nil {
+		return 0, e.joinError(err)
+	}
+	defer e.decryptFields(ctx, e.table) //nolint:errcheck
+
+	return e.exec(ctx, "update", func(tx *gorm.DB) *gorm.DB {
+		return tx.Scopes(e.transaction.scopes...).Scopes(e.returningClause).Updates(e.table)
+	})
+}
+
+// Save writes every column of e.table, zero values included, to the row with its
+// primary key, inserting it when the key is zero. Unlike Update it ignores the
+// query's Where clauses.
+func (e *Entity[E]) Save(ctx context.Context) error {
+	if err := e.encryptFields(ctx, e.table); err != nil {
+		return e.joinError(err)
+	}
+	defer e.decryptFields(ctx, e.table) //nolint:errcheck
+
+	_, err := e.exec(ctx, "update", func(tx *gorm.DB) *gorm.DB {
+		return tx.Save(e.table)
+	})
+
+	return err
+}
+
+// UpdateColumns sets values, keyed by column, on every row matching the query,
+// zero values included.
+func (e *Entity[E]) UpdateColumns(ctx context.Context, values map[string]any) error {
+	_, err := e.exec(ctx, "update", func(tx *gorm.DB) *gorm.DB {
+		return tx.Model(e.table).Scopes(e.transaction.scopes...).Updates(values)
+	})
+
+	return err
+}
+
+func (e *Entity[E]) UpdateTx(ctx context.Context) (tx Transaction, err error) {
+	if err := e.ready(); err != nil {
+		return nil, e.joinError(err)
+	}
+
+	if err := e.encryptFields(ctx, e.table); err != nil {
+		return nil, e.joinError(err)
+	}
+	defer e.decryptFields(ctx, e.table) //nolint:errcheck
+
+	e.transaction.tx = begin(ctx)
+	if err := e.transaction.tx.Error; err != nil {
+		e.transaction.tx = nil
+
+		return nil, e.joinError(err)
+	}
+
+	result := e.transaction.tx.Scopes(e.transaction.scopes...).Scopes(e.returningClause).Updates(e.table)
+	if result.Error != nil {
+		return e.rollback(result.Error)
+	}
+
+	e.transaction.rowsAffected = result.RowsAffected
+	e.afterWrite(ctx, "update", result.RowsAffected)
+
+	return e.commit()
+}
+
+// UpdateCaseByID sets column to a distinct value per primary key in a single statement:
+// UPDATE t SET column = CASE id WHEN ? THEN ? ... END WHERE id IN ?.
+func (e *Entity[E]) UpdateCaseByID(ctx context.Context, column string, values map[any]any) (int64, error) {
+	if len(values) == 0 {
+		return 0, nil
+	}
+
+	pk, err := primaryKey(e.table)
+	if err != nil {
+		return 0, e.joinError(err)
+	}
+
+	ids := make([]any, 0, len(values))
+	for id := range values {
+		ids = append(ids, id)
+	}
+
+	sort.Slice(ids, func(i, j int) bool { return fmt.Sprint(ids[i]) < fmt.Sprint(ids[j]) })
+
+	sql := "CASE ?"
+	vars := []any{clause.Column{Name: pk}}
+
+	for _, id := range ids {
+		sql += " WHEN ? THEN ?"
+		vars = append(vars, id, values[id])
+	}
+
+	sql += " END"
+
+	return e.exec(ctx, "update", func(tx *gorm.DB) *gorm.DB {
+		return tx.Model(e.table).
+			Scopes(e.transaction.scopes...).
+			Where(clause.IN{Column: clause.Column{Name: pk}, Values: ids}).
+			Update(column, gorm.Expr(sql, vars...))
+	})
+}
+
+// UpdateFrom updates the rows of the entity's table joined to sub by on, e.g.
+// assignments {"x": gorm.Expr("s.x")} and on EQ("users.id", gorm.Expr("s.id")).
+// It renders UPDATE ... FROM on Postgres and SQLite and UPDATE ... JOIN on MySQL.
+// A nil on fails with ErrMissingWhereClause.
+func (e *Entity[E]) UpdateFrom(
+	ctx context.Context,
+	sub Subquery,
+	assignments map[string]any,
+	on *Clause,
+) (int64, error) {
+	if on == nil {
+		return 0, e.joinError(ErrMissingWhereClause)
+	}
+
+	if on.err != nil {
+		return 0, e.joinError(on.err)
+	}
+
+	subquery, alias, err := sub.subquery(ctx)
+	if err != nil {
+		return 0, e.joinError(err)
+	}
+
+	cols := make([]string, 0, len(assignments))
+	for col := range assignments {
+		cols = append(cols, col)
+	}
+
+	sort.Strings(cols)
+
+	// Postgres rejects qualified SET columns while MySQL needs them to avoid
+	// ambiguity with the joined columns.
+	qualifier := ""
+	if dialect() == "mysql" {
+		qualifier = e.table.TableName()
+	}
+
+	sets := make([]string, 0, len(cols))
+	setVars := make([]any, 0, len(cols)*2)
+
+	for _, col := range cols {
+		sets = append(sets, "? = ?")
+		setVars = append(setVars, clause.Column{Table: qualifier, Name: col}, assignments[col])
+	}
+
+	onArgs := on.ToSQL()
+	table := clause.Table{Name: e.table.TableName()}
+
+	var (
+		stmt string
+		vars []any
+	)
+
+	switch dialect() {
+	case "postgres", "sqlite":
+		stmt = "UPDATE ? SET " + strings.Join(sets, ", ") + " FROM (?) AS " + alias + " WHERE " + onArgs[0].(string)
+		vars = append(append(append([]any{table}, setVars...), subquery), onArgs[1:]...)
+	case "mysql":
+		stmt = "UPDATE ? JOIN (?) AS " + alias + " ON " + onArgs[0].(string) + " SET " + strings.Join(sets, ", ")
+		vars = append(append([]any{table, subquery}, onArgs[1:]...), setVars...)
+	default:
+		return 0, e.joinError(ErrUnsupportedDriver)
+	}
+
+	return e.exec(ctx, "update", func(tx *gorm.DB) *gorm.DB {
+		return tx.Exec(stmt, vars...)
+	})
+}
+
+func (e *Entity[E]) subquery(ctx context.Context) (*gorm.DB, string, error) {
+	if err := e.ready(); err != nil {
+		return nil, "", err
+	}
+
+	alias := e.alias
+	if len(alias) == 0 {
+		alias = "s"
+	}
+
+	return e.session(ctx).Model(e.table).Scopes(e.transaction.scopes...), alias, nil
 }
 
-func (e *Entity[E]) One(ctx context.Context) (E, error) {
-	var result E
+func (e *Entity[E]) Delete(ctx context.Context) error {
+	_, err := e.DeleteResult(ctx)
 
-	err := db.WithContext(ctx).Scopes(e.transaction.scopes...).First(&result).Error
-	if err != nil {
-		return result, e.joinError(err)
-	}
+	return err
+}
 
-	return result, nil
+// DeleteResult is like Delete but also returns the number of rows deleted.
+func (e *Entity[E]) DeleteResult(ctx context.Context) (int64, error) {
+	return e.exec(ctx, "delete", func(tx *gorm.DB) *gorm.DB {
+		return e.deleteRows(tx)
+	})
 }
 
-func (e *Entity[E]) Count(ctx context.Context) (int64, error) {
-	var count int64
+// DeletePermanently removes the matching rows from the table even when the entity
+// has a gorm.DeletedAt field, soft-deleted rows included.
+func (e *Entity[E]) DeletePermanently(ctx context.Context) error {
+	_, err := e.exec(ctx, "delete", func(tx *gorm.DB) *gorm.DB {
+		return e.deleteRows(tx.Unscoped())
+	})
 
-	err := db.WithContext(ctx).
-		Model(e.table).
-		Scopes(e.transaction.scopes...).
-		Count(&count).Error
-	if err != nil {
-		return -1, e.joinError(err)
-	}
+	return err
+}
 
-	return count, nil
+// CascadeSoftDelete makes Delete and DeleteTx also delete the rows of the named
+// has-one/has-many associations referencing the deleted rows, in the same
+// transaction. Associations with a gorm.DeletedAt field are soft-deleted like
+// the parent.
+func (e *Entity[E]) CascadeSoftDelete(assocs ...string) Entitier[E] {
+	e.cascades = append(e.cascades, assocs...)
+
+	return e
 }
 
-func (e *Entity[E]) Insert(ctx context.Context) error {
-	if e.transaction.tx == nil {
-		return db.WithContext(ctx).Create(e.table).Error
+// deleteRows deletes the rows the query matches. With CascadeSoftDelete it first
+// deletes the rows of the cascaded associations referencing them, within one
+// transaction, or a savepoint of the current one.
+func (e *Entity[E]) deleteRows(tx *gorm.DB) *gorm.DB {
+	if len(e.cascades) == 0 {
+		return tx.Scopes(e.transaction.scopes...).Scopes(e.returningClause).Delete(e.table)
 	}
 
-	err := e.transaction.tx.WithContext(ctx).Create(e.table).Error
-	if err != nil {
-		_, rerr := e.rollback(err)
-		if rerr != nil {
-			return e.joinError(rerr)
+	result := tx
+
+	err := tx.Transaction(func(tx *gorm.DB) error {
+		if err := e.deleteCascades(tx); err != nil {
+			return err
 		}
 
-		return e.joinError(err)
-	}
+		result = tx.Scopes(e.transaction.scopes...).Scopes(e.returningClause).Delete(e.table)
 
-	if e.transaction.commit {
-		_, err := e.commit()
-		if err != nil {
-			return e.joinError(err)
-		}
+		return result.Error
+	})
+	if err != nil && result.Error == nil {
+		_ = result.AddError(err)
 	}
 
-	return nil
+	return result
 }
 
-func (e *Entity[E]) InsertBatch(ctx context.Context, entities []E) error {
-	if e.transaction.tx == nil {
-		return db.WithContext(ctx).CreateInBatches(entities, len(entities)).Error
+// deleteCascades loads the rows the query matches, by the primary key of e.table
+// when it is set, and deletes the rows of the cascaded associations referencing
+// them, soft-deleting those having a gorm.DeletedAt field.
+func (e *Entity[E]) deleteCascades(tx *gorm.DB) error {
+	stmt := &gorm.Statement{DB: tx}
+	if err := stmt.Parse(e.table); err != nil {
+		return err
 	}
 
-	err := e.transaction.tx.WithContext(ctx).CreateInBatches(entities, len(entities)).Error
-	if err != nil {
-		_, rerr := e.rollback(err)
-		if rerr != nil {
-			return e.joinError(rerr)
+	rels := make([]*schema.Relationship, 0, len(e.cascades))
+
+	for _, name := range e.cascades {
+		rel, ok := stmt.Schema.Relationships.Relations[name]
+		if !ok || (rel.Type != schema.HasOne && rel.Type != schema.HasMany) {
+			return fmt.Errorf("%w: %s is no has-one or has-many association", ErrInvalidValue, name)
 		}
 
-		return e.joinError(err)
+		rels = append(rels, rel)
 	}
 
-	if e.transaction.commit {
-		_, err := e.commit()
-		if err != nil {
-			return e.joinError(err)
+	parents := tx.Scopes(e.transaction.scopes...)
+	if field := stmt.Schema.PrioritizedPrimaryField; field != nil {
+		if value, zero := field.ValueOf(tx.Statement.Context, reflect.ValueOf(e.table)); !zero {
+			parents = parents.Where(clause.Eq{
+				Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName},
+				Value:  value,
+			})
 		}
 	}
 
-	return nil
-}
-
-func (e *Entity[E]) InsertTx(ctx context.Context) (tx Transaction, err error) {
-	e.transaction.tx = db.WithContext(ctx).Begin()
+	matched := make([]E, 0)
 
-	err = e.transaction.tx.Create(e.table).Error
-	if err != nil {
-		return e.rollback(err)
+	found := parents.Find(&matched)
+	if found.Error != nil || len(matched) == 0 {
+		return found.Error
 	}
 
-	return e.commit()
-}
-
-func (e *Entity[E]) Update(ctx context.Context) error {
-	if e.transaction.tx == nil {
-		return db.WithContext(ctx).Scopes(e.transaction.scopes...).Updates(e.table).Error
-	}
+	for _, rel := range rels {
+		child := reflect.New(rel.FieldSchema.ModelType).Interface()
+		children := tx.Session(&gorm.Session{NewDB: true}).
+			Model(child).
+			Where(clause.Where{Exprs: rel.ToQueryConditions(tx.Statement.Context, reflect.ValueOf(matched))})
 
-	err := e.transaction.tx.WithContext(ctx).
-		Scopes(e.transaction.scopes...).
-		Updates(e.table).Error
-	if err != nil {
-		_, rerr := e.rollback(err)
-		if rerr != nil {
-			return e.joinError(rerr)
+		if found.Statement.Unscoped {
+			children = children.Unscoped()
 		}
 
-		return e.joinError(err)
-	}
-
-	if e.transaction.commit {
-		_, err := e.commit()
-		if err != nil {
-			return e.joinError(err)
+		if err := children.Delete(child).Error; err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
 
-func (e *Entity[E]) UpdateTx(ctx context.Context) (tx Transaction, err error) {
-	e.transaction.tx = db.Begin()
-
-	err = e.transaction.tx.WithContext(ctx).Scopes(e.transaction.scopes...).Updates(e.table).Error
-	if err != nil {
-		return e.rollback(err)
+func (e *Entity[E]) DeleteTx(ctx context.Context) (tx Transaction, err error) {
+	if err := e.ready(); err != nil {
+		return nil, e.joinError(err)
 	}
 
-	return e.commit()
-}
+	e.transaction.tx = begin(ctx)
+	if err := e.transaction.tx.Error; err != nil {
+		e.transaction.tx = nil
 
-func (e *Entity[E]) Delete(ctx context.Context) error {
-	if e.transaction.tx == nil {
-		return db.WithContext(ctx).Scopes(e.transaction.scopes...).Delete(e.table).Error
+		return nil, e.joinError(err)
 	}
 
-	err := e.transaction.tx.WithContext(ctx).Delete(e.table).Error
-	if err != nil {
-		_, rerr := e.rollback(err)
-		if rerr != nil {
-			return e.joinError(rerr)
-		}
-
-		return e.joinError(err)
+	result := e.deleteRows(e.transaction.tx)
+	if result.Error != nil {
+		return e.rollback(result.Error)
 	}
 
-	if e.transaction.commit {
-		_, err := e.commit()
-		if err != nil {
-			return e.joinError(err)
-		}
-	}
+	e.transaction.rowsAffected = result.RowsAffected
+	e.afterWrite(ctx, "delete", result.RowsAffected)
 
-	return nil
+	return e.commit()
 }
 
-func (e *Entity[E]) DeleteTx(ctx context.Context) (tx Transaction, err error) {
-	e.transaction.tx = db.Begin()
+// Truncate removes every row of the entity's table, falling back to
+// DELETE FROM on SQLite which has no TRUNCATE.
+func (e *Entity[E]) Truncate(ctx context.Context) error {
+	stmt := "TRUNCATE TABLE ?"
+	if dialect() == "sqlite" {
+		stmt = "DELETE FROM ?"
+	}
 
-	err = e.transaction.tx.WithContext(ctx).Scopes(e.transaction.scopes...).Delete(e.table).Error
-	if err != nil {
-		return e.rollback(err)
+	_, err := e.exec(ctx, "truncate", func(tx *gorm.DB) *gorm.DB {
+		return tx.Exec(stmt, clause.Table{Name: e.table.TableName()})
+	})
+
+	return err
+}
+
+// TruncateCascade truncates the entity's table with RESTART IDENTITY CASCADE,
+// resetting sequences and truncating referencing tables. It is Postgres only.
+func (e *Entity[E]) TruncateCascade(ctx context.Context) error {
+	if dialect() != "postgres" {
+		return e.joinError(ErrUnsupportedDriver)
 	}
 
-	return e.commit()
+	_, err := e.exec(ctx, "truncate", func(tx *gorm.DB) *gorm.DB {
+		return tx.Exec("TRUNCATE TABLE ? RESTART IDENTITY CASCADE", clause.Table{Name: e.table.TableName()})
+	})
+
+	return err
 }
 
 func (e *Entity[E]) SetTx(tx Transaction, commit bool) Entitier[E] {
-	e.transaction.tx = tx.(*transaction).tx
+	e.transaction.root = tx.(*transaction).owner()
+	e.transaction.tx = e.transaction.root.tx
 	e.transaction.commit = commit
 
 	return e
 }
 
 func (e *Entity[E]) Query(sql string, values ...any) error {
+	if err := e.ready(); err != nil {
+		return e.joinError(err)
+	}
+
 	err := db.Scopes(e.transaction.scopes...).Raw(sql, values...).Scan(&e.table).Error
 	if err != nil {
 		return e.joinError(err)
@@ -449,9 +2351,30 @@ func (e *Entity[E]) Query(sql string, values ...any) error {
 }
 
 func (e *Entity[E]) QueryRows(sql string, values ...any) ([]E, error) {
+	if err := e.ready(); err != nil {
+		return nil, e.joinError(err)
+	}
+
 	result := make([]E, 0)
 
-	err := db.Scopes(e.transaction.scopes...).Raw(sql, values...).Scan(&e.table).Error
+	err := db.Scopes(e.transaction.scopes...).Raw(sql, values...).Scan(&result).Error
+	if err != nil {
+		return nil, e.joinError(err)
+	}
+
+	return result, nil
+}
+
+// QueryRowsCtx runs a fully custom SELECT and maps its rows to []E. Unlike the
+// builder methods it ignores any accumulated Where, Select or other scopes.
+func (e *Entity[E]) QueryRowsCtx(ctx context.Context, sql string, values ...any) ([]E, error) {
+	if err := ready(); err != nil {
+		return nil, e.joinError(err)
+	}
+
+	result := make([]E, 0)
+
+	err := db.WithContext(ctx).Raw(sql, values...).Scan(&result).Error
 	if err != nil {
 		return nil, e.joinError(err)
 	}
@@ -460,6 +2383,10 @@ func (e *Entity[E]) QueryRows(sql string, values ...any) ([]E, error) {
 }
 
 func (e *Entity[E]) Exec(sql string, values ...any) error {
+	if err := e.ready(); err != nil {
+		return e.joinError(err)
+	}
+
 	err := db.Scopes(e.transaction.scopes...).Exec(sql, values...).Error
 	if err != nil {
 		return e.joinError(err)
@@ -468,32 +2395,220 @@ func (e *Entity[E]) Exec(sql string, values ...any) error {
 	return nil
 }
 
+// Aggregate streams the rows of query, folding each one into the accumulator
+// starting from init, without materializing the result set.
+func Aggregate[E entity, A any](ctx context.Context, query Entitier[E], init A, fold func(A, E) A) (A, error) {
+	acc := init
+
+	rows, err := query.Rows(ctx)
+	if err != nil {
+		return acc, err
+	}
+	defer rows.Close()
+
+	e, _ := query.(*Entity[E])
+
+	for rows.Next() {
+		row := newEntity[E]()
+
+		if err := db.ScanRows(rows, &row); err != nil {
+			return acc, err
+		}
+
+		if e != nil {
+			if err := e.decryptFields(ctx, &row); err != nil {
+				return acc, err
+			}
+		}
+
+		acc = fold(acc, row)
+	}
+
+	return acc, rows.Err()
+}
+
+// newEntity allocates a zero E, pointing to a new value when E is a pointer.
+func newEntity[E entity]() E {
+	var ent E
+
+	t := reflect.TypeOf(&ent).Elem()
+	if t.Kind() == reflect.Ptr {
+		return reflect.New(t.Elem()).Interface().(E)
+	}
+
+	return ent
+}
+
+// fail records err, when not nil, as the build error of the entity unless an
+// earlier one was recorded.
+func (e *Entity[E]) fail(err error) {
+	if e.buildErr == nil {
+		e.buildErr = err
+	}
+}
+
+// ready is like the package ready but also reports the build error of the entity.
+func (e *Entity[E]) ready() error {
+	if e.buildErr != nil {
+		return e.buildErr
+	}
+
+	return ready()
+}
+
+// withTimeout derives the context of one query from ctx, bounded by Timeout.
+func (e *Entity[E]) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if e.timeout <= 0 {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeout(ctx, e.timeout)
+}
+
+// session returns the handle reads run on: the current transaction if one was
+// set, the package db otherwise.
+func (e *Entity[E]) session(ctx context.Context) *gorm.DB {
+	if e.transaction.tx != nil {
+		return e.transaction.tx.WithContext(ctx)
+	}
+
+	return db.WithContext(ctx)
+}
+
+// addWhere adds where, a scope applying conditions, to the query.
+func (e *Entity[E]) addWhere(where func(*gorm.DB) *gorm.DB) {
+	e.transaction.scopes = append(e.transaction.scopes, where)
+	e.wheres = append(e.wheres, where)
+}
+
+// bindScope adds the scope built by bind over e. Scopes reading the entity are
+// added through it, so that a clone's scopes read the clone.
+func (e *Entity[E]) bindScope(bind func(e *Entity[E]) func(*gorm.DB) *gorm.DB) {
+	if e.bound == nil {
+		e.bound = make(map[int]func(*Entity[E]) func(*gorm.DB) *gorm.DB)
+	}
+
+	e.bound[len(e.transaction.scopes)] = bind
+	e.transaction.scopes = append(e.transaction.scopes, bind(e))
+}
+
+// read runs fn on the session and, with SetReplicaFallback enabled, retries it
+// once on the primary when a replica connection fails outside a transaction.
+func (e *Entity[E]) read(ctx context.Context, fn func(*gorm.DB) error) error {
+	if err := e.ready(); err != nil {
+		return err
+	}
+
+	ctx, cancel := e.withTimeout(ctx)
+	defer cancel()
+
+	err := fn(e.session(ctx))
+	if err == nil || !replicaFallback || e.transaction.tx != nil || !isConnError(err) {
+		return err
+	}
+
+	db.Logger.Warn(ctx, "entigorm: replica read failed, retrying on primary: %v", err)
+
+	return fn(e.session(ctx).Clauses(dbresolver.Write))
+}
+
+// exec runs a write against the current transaction, if any, rolling it back on
+// failure and committing it when requested, and returns the affected rows.
+func (e *Entity[E]) exec(ctx context.Context, op string, fn func(*gorm.DB) *gorm.DB) (int64, error) {
+	if err := e.ready(); err != nil {
+		return 0, e.joinError(err)
+	}
+
+	// afterWrite gets ctx: the hooks it queues run at commit, after queryCtx
+	// is cancelled.
+	queryCtx, cancel := e.withTimeout(ctx)
+	defer cancel()
+
+	if e.transaction.tx == nil {
+		result := fn(db.WithContext(queryCtx))
+		if result.Error != nil {
+			return 0, e.joinError(result.Error)
+		}
+
+		e.afterWrite(ctx, op, result.RowsAffected)
+
+		return result.RowsAffected, nil
+	}
+
+	result := fn(e.transaction.tx.WithContext(queryCtx))
+	if result.Error != nil {
+		_, err := e.rollback(result.Error)
+
+		return 0, err
+	}
+
+	e.afterWrite(ctx, op, result.RowsAffected)
+
+	if e.transaction.commit {
+		_, err := e.commit()
+		if err != nil {
+			return 0, e.joinError(err)
+		}
+	}
+
+	return result.RowsAffected, nil
+}
+
+// afterWrite reports a successful write to the OnWrite hooks, deferring it until
+// commit when the write happened inside a transaction.
+func (e *Entity[E]) afterWrite(ctx context.Context, op string, rowsAffected int64) {
+	if len(writeHooks) == 0 {
+		return
+	}
+
+	table := e.table.TableName()
+	notify := func() {
+		for _, hook := range writeHooks {
+			hook(ctx, op, table, rowsAffected)
+		}
+	}
+
+	if e.transaction.tx == nil {
+		notify()
+
+		return
+	}
+
+	owner := e.transaction.owner()
+	owner.afterCommit = append(owner.afterCommit, notify)
+}
+
 func (e *Entity[E]) commit() (tx Transaction, err error) {
 	if e.transaction.commit {
-		return e.transaction, e.transaction.tx.Commit().Error
+		return e.transaction, e.transaction.Commit()
 	}
 
 	return e.transaction, nil
 }
 
+// rollback undoes a failed write, back to the latest savepoint if one was set,
+// and returns err joined with the error of the rollback, if any.
 func (e *Entity[E]) rollback(err error) (Transaction, error) {
-	if len(e.transaction.savePoint) > 0 {
-		rErr := e.transaction.tx.RollbackTo(e.transaction.savePoint).Error
-		if rErr != nil {
-			e.error = rErr
+	owner := e.transaction.owner()
+
+	if savePoint := owner.savePoint; len(savePoint) > 0 {
+		if rErr := e.transaction.tx.RollbackTo(savePoint).Error; rErr != nil {
+			return nil, e.joinError(errors.Join(err, rErr))
 		}
 
+		owner.dropQueued(savePoint)
+
 		return nil, e.joinError(err)
 	}
 
-	rErr := e.transaction.tx.Rollback().Error
-	if rErr != nil {
-		e.error = rErr
-
-		return nil, e.joinError(err)
+	if rErr := e.transaction.tx.Rollback().Error; rErr != nil {
+		return nil, e.joinError(errors.Join(err, rErr))
 	}
 
-	return e.transaction, nil
+	owner.done = true
+	owner.afterCommit = nil
+
+	return nil, e.joinError(err)
 }
 
 func (e *Entity[E]) joinError(err error) error {
@@ -506,6 +2621,141 @@ func (e *Entity[E]) joinError(err error) error {
 	return e.error
 }
 
+// orCondition marks a WHERE condition added by OrWhere, which groupOrConditions
+// ORs with the conditions preceding it.
+type orCondition struct {
+	expr clause.Expr
+}
+
+func (c orCondition) Build(builder clause.Builder) {
+	c.expr.Build(builder)
+}
+
+// groupOrConditions replaces each orCondition of the WHERE clause of tx and the
+// conditions before it by their OR, each side parenthesized. gorm hides from a
+// scope the conditions of the previous ones, so that is done once all are merged.
+func groupOrConditions(tx *gorm.DB) {
+	c, ok := tx.Statement.Clauses["WHERE"]
+	if !ok {
+		return
+	}
+
+	where, ok := c.Expression.(clause.Where)
+	if !ok {
+		return
+	}
+
+	exprs := make([]clause.Expression, 0, len(where.Exprs))
+
+	for _, expr := range where.Exprs {
+		or, ok := expr.(orCondition)
+
+		switch {
+		case !ok:
+			exprs = append(exprs, expr)
+		case len(exprs) == 0:
+			exprs = append(exprs, or.expr)
+		default:
+			exprs = []clause.Expression{clause.Expr{SQL: "(?) OR (?)", Vars: []any{conditions{exprs}, or.expr}}}
+		}
+	}
+
+	c.Expression = clause.Where{Exprs: exprs}
+	tx.Statement.Clauses["WHERE"] = c
+}
+
+// conditions renders exprs joined by AND without parentheses, as WHERE does.
+type conditions struct {
+	exprs []clause.Expression
+}
+
+func (c conditions) Build(builder clause.Builder) {
+	clause.Where{Exprs: c.exprs}.Build(builder)
+}
+
+func applySelects(db *gorm.DB, selects []clause.Expr) *gorm.DB {
+	cols := make([]string, 0, len(selects))
+	vars := make([]any, 0)
+
+	for _, expr := range selects {
+		cols = append(cols, expr.SQL)
+		vars = append(vars, expr.Vars...)
+	}
+
+	if len(vars) == 0 {
+		return db.Select(cols)
+	}
+
+	return db.Select(strings.Join(cols, ", "), vars...)
+}
+
+// onConflictClause builds the ON CONFLICT clause of an upsert. guard only
+// applies to DO UPDATE, so it fails with ErrInvalidValue without updateColumns.
+func onConflictClause(conflictColumns, updateColumns []string, guard []*Clause) (clause.OnConflict, error) {
+	if len(updateColumns) == 0 && len(guard) > 0 {
+		return clause.OnConflict{}, ErrInvalidValue
+	}
+
+	onConflict := clause.OnConflict{
+		Columns:   make([]clause.Column, 0, len(conflictColumns)),
+		DoUpdates: clause.AssignmentColumns(updateColumns),
+		DoNothing: len(updateColumns) == 0,
+	}
+
+	for _, col := range conflictColumns {
+		onConflict.Columns = append(onConflict.Columns, clause.Column{Name: col})
+	}
+
+	if len(guard) > 0 && dialect() != "postgres" && dialect() != "sqlite" {
+		return clause.OnConflict{}, ErrUnsupportedDriver
+	}
+
+	for _, g := range guard {
+		if g == nil {
+			return clause.OnConflict{}, ErrInvalidValue
+		}
+
+		args := g.ToSQL()
+		if err := g.check(args[1:]); err != nil {
+			return clause.OnConflict{}, err
+		}
+
+		onConflict.Where.Exprs = append(onConflict.Where.Exprs, clause.Expr{SQL: args[0].(string), Vars: args[1:]})
+	}
+
+	return onConflict, nil
+}
+
+func primaryKey(table any) (string, error) {
+	field, err := primaryField(table)
+	if err != nil {
+		return "", err
+	}
+
+	return field.DBName, nil
+}
+
+func primaryField(table any) (*schema.Field, error) {
+	if err := ready(); err != nil {
+		return nil, err
+	}
+
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(table); err != nil {
+		return nil, err
+	}
+
+	if stmt.Schema.PrioritizedPrimaryField == nil {
+		return nil, ErrPrimaryKeyRequired
+	}
+
+	return stmt.Schema.PrioritizedPrimaryField, nil
+}
+
+var columnRegexp = regexp.MustCompile(`(?i)^\s*(\w+\.)?(\w+|\*)(\s+(AS\s+)?\w+)?\s*$`)
+
+var aliasRegexp = regexp.MustCompile(`(?i)^\s*(.+?)\s+AS\s+["'\x60]?(\w+)["'\x60]?\s*$`)
+
 func newVar(v any) any {
 	t := reflect.TypeOf(v)
 
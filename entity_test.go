@@ -0,0 +1,2193 @@
+package entigorm
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/utils/tests"
+	"gorm.io/plugin/dbresolver"
+)
+
+type testUser struct {
+	ID   uint
+	Name string
+	Age  int
+}
+
+func (*testUser) TableName() string { return "users" }
+
+// openTestDB makes a fresh in-memory SQLite database the package db for the
+// duration of t, migrated for models. The pool is limited to one connection,
+// each connection of an in-memory database seeing its own database.
+func openTestDB(t *testing.T, models ...any) *gorm.DB {
+	t.Helper()
+
+	gormdb, err := gorm.Open(translatingDialector{sqlite.Open("file::memory:")}, &gorm.Config{
+		Logger:         logger.Default.LogMode(logger.Silent),
+		TranslateError: true,
+	})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	sqlDB, err := gormdb.DB()
+	if err != nil {
+		t.Fatalf("pool: %v", err)
+	}
+
+	sqlDB.SetMaxOpenConns(1)
+
+	Init(gormdb)
+	t.Cleanup(func() {
+		sqlDB.Close()
+		resetGlobals()
+	})
+
+	if err := gormdb.AutoMigrate(models...); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	return gormdb
+}
+
+// translatingDialector translates the unique constraint errors of SQLite to
+// gorm.ErrDuplicatedKey, as the drivers translating errors do.
+type translatingDialector struct {
+	gorm.Dialector
+}
+
+func (d translatingDialector) Translate(err error) error {
+	if err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed") {
+		return gorm.ErrDuplicatedKey
+	}
+
+	return err
+}
+
+func (d translatingDialector) SavePoint(tx *gorm.DB, name string) error {
+	return d.Dialector.(gorm.SavePointerDialectorInterface).SavePoint(tx, name)
+}
+
+func (d translatingDialector) RollbackTo(tx *gorm.DB, name string) error {
+	return d.Dialector.(gorm.SavePointerDialectorInterface).RollbackTo(tx, name)
+}
+
+// namedDialector renders statements like the dummy dialector of gorm while
+// reporting name, for the dialect-specific SQL of entigorm.
+type namedDialector struct {
+	tests.DummyDialector
+	name string
+}
+
+func (d namedDialector) Name() string { return d.name }
+
+// openDryRunDB makes a DryRun db reporting dialect the package db for the
+// duration of t, statements being rendered but never run.
+func openDryRunDB(t *testing.T, dialect string) {
+	t.Helper()
+
+	gormdb, err := gorm.Open(namedDialector{name: dialect}, &gorm.Config{DryRun: true, Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	Init(gormdb)
+	t.Cleanup(resetGlobals)
+}
+
+// resetGlobals restores the package configuration that tests change.
+func resetGlobals() {
+	db = nil
+	closed = false
+	defaultLimit = 0
+	writeHooks = nil
+	replicaFallback = false
+	queryLogger = nil
+	slowQueryThreshold = 0
+	slowQueryHooks = nil
+	metrics = nil
+}
+
+// insertUsers inserts a user for each name, aged by its position from 1.
+func insertUsers(t *testing.T, names ...string) []*testUser {
+	t.Helper()
+
+	users := make([]*testUser, 0, len(names))
+
+	for i, name := range names {
+		user := &testUser{Name: name, Age: i + 1}
+		if err := SQL(user).Insert(context.Background()); err != nil {
+			t.Fatalf("insert %s: %v", name, err)
+		}
+
+		users = append(users, user)
+	}
+
+	return users
+}
+
+func TestQueryRows(t *testing.T) {
+	openTestDB(t, &testUser{})
+	insertUsers(t, "ann", "bob")
+
+	users, err := SQL(&testUser{}).QueryRows("SELECT * FROM users ORDER BY id")
+	if err != nil {
+		t.Fatalf("QueryRows: %v", err)
+	}
+
+	if len(users) != 2 || users[0].Name != "ann" || users[1].Name != "bob" {
+		t.Fatalf("QueryRows = %+v, want ann and bob", users)
+	}
+}
+
+// dryRun returns the SELECT Find would run for query, with its placeholders.
+func dryRun[E entity](t *testing.T, query Entitier[E]) (string, []any) {
+	t.Helper()
+
+	e, ok := query.(*Entity[E])
+	if !ok {
+		t.Fatalf("query is %T, not an *Entity", query)
+	}
+
+	var result []E
+
+	stmt := e.findQuery(db.Session(&gorm.Session{DryRun: true})).Find(&result).Statement
+	if stmt.Error != nil {
+		t.Fatalf("dry run: %v", stmt.Error)
+	}
+
+	return stmt.SQL.String(), stmt.Vars
+}
+
+func TestOrWhere(t *testing.T) {
+	openTestDB(t, &testUser{})
+	insertUsers(t, "ann", "bob", "cid")
+
+	query := SQL(&testUser{}).Where(EQ("name", "ann")).OrWhere(EQ("name", "bob"))
+
+	sql, vars := dryRun(t, query)
+	if !strings.HasSuffix(sql, "WHERE (name = ?) OR (name = ?)") || !reflect.DeepEqual(vars, []any{"ann", "bob"}) {
+		t.Fatalf("OrWhere renders %q %v", sql, vars)
+	}
+
+	query = query.Where(EQ("age", 2))
+
+	sql, vars = dryRun(t, query)
+	if !strings.HasSuffix(sql, "WHERE ((name = ?) OR (name = ?)) AND age = ?") || !reflect.DeepEqual(vars, []any{"ann", "bob", 2}) {
+		t.Fatalf("Where after OrWhere renders %q %v", sql, vars)
+	}
+
+	users, err := query.Find(context.Background())
+	if err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+
+	if len(users) != 1 || users[0].Name != "bob" {
+		t.Fatalf("Find = %+v, want bob", users)
+	}
+}
+
+type loggedQuery struct {
+	sql  string
+	args []any
+	err  error
+}
+
+// capturingLogger records the statements it receives.
+type capturingLogger struct {
+	queries []loggedQuery
+}
+
+func (l *capturingLogger) LogQuery(_ context.Context, sql string, args []any, _ time.Duration, _ int64, err error) {
+	l.queries = append(l.queries, loggedQuery{sql: sql, args: args, err: err})
+}
+
+func TestSetLogger(t *testing.T) {
+	openTestDB(t, &testUser{})
+	insertUsers(t, "ann")
+
+	logger := &capturingLogger{}
+	SetLogger(logger)
+
+	query := SQL(&testUser{}).Where(EQ("name", "ann"))
+	query.ExplainSQL(context.Background())
+
+	if len(logger.queries) != 0 {
+		t.Fatalf("ExplainSQL logged %+v", logger.queries)
+	}
+
+	if _, err := query.Find(context.Background()); err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+
+	if len(logger.queries) != 1 {
+		t.Fatalf("logged %d queries, want 1", len(logger.queries))
+	}
+
+	got := logger.queries[0]
+	if !strings.Contains(got.sql, "WHERE name = ?") || !reflect.DeepEqual(got.args, []any{"ann"}) || got.err != nil {
+		t.Fatalf("logged %+v", got)
+	}
+}
+
+func TestFirstOrCreate(t *testing.T) {
+	openTestDB(t, &testUser{})
+	insertUsers(t, "ann")
+
+	var writes []string
+	OnWrite(func(_ context.Context, op, table string, _ int64) {
+		writes = append(writes, op+" "+table)
+	})
+
+	found, err := SQL(&testUser{Name: "other"}).Where(EQ("name", "ann")).Select("id").FirstOrCreate(context.Background())
+	if err != nil {
+		t.Fatalf("FirstOrCreate found: %v", err)
+	}
+
+	if found.ID != 1 || found.Name != "ann" || len(writes) != 0 {
+		t.Fatalf("found %+v with writes %v, want ann and no write", found, writes)
+	}
+
+	created, err := SQL(&testUser{Name: "bob", Age: 7}).Where(EQ("name", "bob")).Select("id").FirstOrCreate(context.Background())
+	if err != nil {
+		t.Fatalf("FirstOrCreate created: %v", err)
+	}
+
+	if created.ID != 2 || !reflect.DeepEqual(writes, []string{"insert users"}) {
+		t.Fatalf("created %+v with writes %v, want id 2 and one insert", created, writes)
+	}
+
+	stored, err := SQL(&testUser{}).Where(EQ("id", 2)).One(context.Background())
+	if err != nil {
+		t.Fatalf("One: %v", err)
+	}
+
+	if stored.Name != "bob" || stored.Age != 7 {
+		t.Fatalf("stored %+v, want every column inserted", stored)
+	}
+
+	if _, err := SQL(&testUser{Name: "cid"}).FirstOrCreate(context.Background()); !errors.Is(err, ErrMissingWhereClause) {
+		t.Fatalf("FirstOrCreate without Where = %v, want ErrMissingWhereClause", err)
+	}
+}
+
+func TestUpsert(t *testing.T) {
+	openTestDB(t, &testUser{})
+	insertUsers(t, "ann")
+
+	ctx := context.Background()
+
+	if err := SQL(&testUser{ID: 1, Name: "anna", Age: 9}).Upsert(ctx, []string{"id"}, []string{"name"}); err != nil {
+		t.Fatalf("Upsert: %v", err)
+	}
+
+	user, err := SQL(&testUser{}).Where(EQ("id", 1)).One(ctx)
+	if err != nil {
+		t.Fatalf("One: %v", err)
+	}
+
+	if user.Name != "anna" || user.Age != 1 {
+		t.Fatalf("upserted %+v, want only the name updated", user)
+	}
+
+	err = SQL(&testUser{ID: 1, Name: "ann"}).Upsert(ctx, []string{"id"}, nil, GT("excluded.age", 0))
+	if !errors.Is(err, ErrInvalidValue) {
+		t.Fatalf("Upsert with a guard and no update columns = %v, want ErrInvalidValue", err)
+	}
+}
+
+func TestHavingAliasWithParam(t *testing.T) {
+	openDryRunDB(t, "postgres")
+
+	query := SQL(&testUser{}).
+		SelectExpr("name").
+		SelectExpr("SUM(age) AS total").
+		GroupBy("name").
+		Having(GT("total", Param("min")).Bind("min", 5))
+
+	sql, vars := dryRun(t, query)
+	if !strings.HasSuffix(sql, "HAVING SUM(age) > ?") || !reflect.DeepEqual(vars, []any{5}) {
+		t.Fatalf("Having renders %q %v", sql, vars)
+	}
+}
+
+// slowQuery streams rows from SQLite long enough for a short deadline to
+// interrupt it between two of them.
+const slowQuery = "WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < 100000000) " +
+	"SELECT i AS id FROM n"
+
+func TestPoolExhausted(t *testing.T) {
+	gormdb := openTestDB(t, &testUser{})
+
+	sqlDB, err := gormdb.DB()
+	if err != nil {
+		t.Fatalf("pool: %v", err)
+	}
+
+	conn, err := sqlDB.Conn(context.Background())
+	if err != nil {
+		t.Fatalf("hold the only connection: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err = SQL(&testUser{}).Find(ctx)
+	if !errors.Is(err, ErrPoolExhausted) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Find on a busy pool = %v, want ErrPoolExhausted", err)
+	}
+
+	conn.Close()
+
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err = SQL(&testUser{}).QueryRowsCtx(ctx, slowQuery)
+	if !errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrPoolExhausted) {
+		t.Fatalf("slow statement = %v, want a timeout other than ErrPoolExhausted", err)
+	}
+}
+
+func TestPoolExhaustedConcurrently(t *testing.T) {
+	openTestDB(t, &testUser{})
+
+	slow := make(chan error, 1)
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+		defer cancel()
+
+		_, err := SQL(&testUser{}).QueryRowsCtx(ctx, slowQuery)
+		slow <- err
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := SQL(&testUser{}).Find(ctx); !errors.Is(err, ErrPoolExhausted) {
+		t.Errorf("Find waiting for the busy connection = %v, want ErrPoolExhausted", err)
+	}
+
+	if err := <-slow; !errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrPoolExhausted) {
+		t.Fatalf("slow statement = %v, want a timeout other than ErrPoolExhausted", err)
+	}
+}
+
+func TestSelectStringAgg(t *testing.T) {
+	openTestDB(t, &testUser{})
+
+	for _, user := range []*testUser{{Name: "ann", Age: 30}, {Name: "bob", Age: 30}, {Name: "cid", Age: 40}} {
+		if err := SQL(user).Insert(context.Background()); err != nil {
+			t.Fatalf("insert: %v", err)
+		}
+	}
+
+	var groups []struct {
+		Age   int
+		Names string
+	}
+
+	err := SQL(&testUser{}).
+		SelectExpr("age").
+		SelectStringAgg("name", ", ", "names").
+		GroupBy("age").
+		OrderBy("age", true).
+		ScanInto(context.Background(), &groups)
+	if err != nil {
+		t.Fatalf("ScanInto: %v", err)
+	}
+
+	if len(groups) != 2 || groups[0].Names != "ann, bob" || groups[1].Names != "cid" {
+		t.Fatalf("aggregated %+v", groups)
+	}
+
+	openDryRunDB(t, "mysql")
+
+	_, err = SQL(&testUser{}).SelectStringAgg("name", "', '", "names").GroupBy("age").Find(context.Background())
+	if !errors.Is(err, ErrInvalidValue) {
+		t.Fatalf("MySQL separator with a quote = %v, want ErrInvalidValue", err)
+	}
+}
+
+func TestExplain(t *testing.T) {
+	openTestDB(t, &testUser{})
+	insertUsers(t, "ann")
+
+	err := WithTransaction(context.Background(), func(tx Transaction) error {
+		plan, err := SQL(&testUser{}).SetTx(tx, false).Where(EQ("name", "ann")).Timeout(time.Second).Explain(context.Background())
+		if err != nil {
+			return err
+		}
+
+		if plan == "" {
+			t.Error("Explain returned an empty plan")
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Explain in a transaction: %v", err)
+	}
+}
+
+func TestOnWrite(t *testing.T) {
+	openTestDB(t, &testUser{})
+
+	ctx := context.Background()
+
+	var writes []string
+	OnWrite(func(_ context.Context, op, table string, rowsAffected int64) {
+		writes = append(writes, fmt.Sprintf("%s %s %d", op, table, rowsAffected))
+	})
+
+	user := &testUser{Name: "ann"}
+	if err := SQL(user).Insert(ctx); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+
+	user.Name = "anna"
+	if err := SQL(user).Where(EQ("id", user.ID)).Update(ctx); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	if want := []string{"insert users 1", "update users 1"}; !reflect.DeepEqual(writes, want) {
+		t.Fatalf("writes = %v, want %v", writes, want)
+	}
+}
+
+func TestOnWriteSavePoint(t *testing.T) {
+	openTestDB(t, &testUser{})
+
+	ctx := context.Background()
+
+	var writes []string
+	OnWrite(func(_ context.Context, op, _ string, _ int64) {
+		writes = append(writes, op)
+	})
+
+	err := WithTransaction(ctx, func(tx Transaction) error {
+		if err := SQL(&testUser{Name: "kept"}).SetTx(tx, false).Insert(ctx); err != nil {
+			return err
+		}
+
+		nestedErr := tx.Nested(ctx, func(tx Transaction) error {
+			if err := SQL(&testUser{Name: "nested"}).SetTx(tx, false).Insert(ctx); err != nil {
+				return err
+			}
+
+			return errors.New("undo the nested insert")
+		})
+		if nestedErr == nil {
+			t.Error("Nested succeeded despite its error")
+		}
+
+		if err := tx.SavePoint("sp"); err != nil {
+			return err
+		}
+
+		if err := SQL(&testUser{Name: "undone"}).SetTx(tx, false).Insert(ctx); err != nil {
+			return err
+		}
+
+		return tx.RollbackTo("sp")
+	})
+	if err != nil {
+		t.Fatalf("WithTransaction: %v", err)
+	}
+
+	if !reflect.DeepEqual(writes, []string{"insert"}) {
+		t.Fatalf("writes = %v, want only the kept insert", writes)
+	}
+}
+
+// openMySQLDryRunDB makes a DryRun db of the mysql dialector, reporting
+// version as its server version, the package db for the duration of t.
+func openMySQLDryRunDB(t *testing.T, version string) {
+	t.Helper()
+
+	dialector := mysql.New(mysql.Config{ServerVersion: version, SkipInitializeWithVersion: true})
+
+	gormdb, err := gorm.Open(dialector, &gorm.Config{DryRun: true, DisableAutomaticPing: true, Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	Init(gormdb)
+	t.Cleanup(resetGlobals)
+}
+
+func TestAsOf(t *testing.T) {
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	openMySQLDryRunDB(t, "10.6.12-MariaDB")
+
+	sql, vars := dryRun(t, SQL(&testUser{}).AsOf(at))
+	if !strings.Contains(sql, "FROM `users` FOR SYSTEM_TIME AS OF ?") || !reflect.DeepEqual(vars, []any{at}) {
+		t.Fatalf("AsOf renders %q %v", sql, vars)
+	}
+
+	openMySQLDryRunDB(t, "8.0.36")
+
+	if _, err := SQL(&testUser{}).AsOf(at).Find(context.Background()); !errors.Is(err, ErrUnsupportedDriver) {
+		t.Fatalf("AsOf on MySQL = %v, want ErrUnsupportedDriver", err)
+	}
+}
+
+func TestIsActive(t *testing.T) {
+	openTestDB(t, &testUser{})
+
+	tx, err := SQL(&testUser{Name: "ann"}).InsertTx(context.Background())
+	if err != nil {
+		t.Fatalf("InsertTx: %v", err)
+	}
+
+	if !tx.IsActive() {
+		t.Fatal("transaction inactive before Commit")
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+
+	if tx.IsActive() {
+		t.Fatal("transaction still active after Commit")
+	}
+
+	if err := tx.Rollback(); !errors.Is(err, ErrInvalidTransaction) {
+		t.Fatalf("Rollback after Commit = %v, want ErrInvalidTransaction", err)
+	}
+}
+
+func TestUpsertGuard(t *testing.T) {
+	openTestDB(t, &testUser{})
+
+	ctx := context.Background()
+
+	if err := SQL(&testUser{Name: "ann", Age: 5}).Insert(ctx); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+
+	newer := GT("excluded.age", gorm.Expr("users.age"))
+
+	if err := SQL(&testUser{ID: 1, Name: "stale", Age: 3}).Upsert(ctx, []string{"id"}, []string{"name", "age"}, newer); err != nil {
+		t.Fatalf("stale Upsert: %v", err)
+	}
+
+	user, err := SQL(&testUser{}).Where(EQ("id", 1)).One(ctx)
+	if err != nil {
+		t.Fatalf("One: %v", err)
+	}
+
+	if user.Name != "ann" || user.Age != 5 {
+		t.Fatalf("stale row overwrote the newer one: %+v", user)
+	}
+
+	if err := SQL(&testUser{ID: 1, Name: "fresh", Age: 9}).Upsert(ctx, []string{"id"}, []string{"name", "age"}, newer); err != nil {
+		t.Fatalf("fresh Upsert: %v", err)
+	}
+
+	user, err = SQL(&testUser{}).Where(EQ("id", 1)).One(ctx)
+	if err != nil {
+		t.Fatalf("One: %v", err)
+	}
+
+	if user.Name != "fresh" || user.Age != 9 {
+		t.Fatalf("newer row not written: %+v", user)
+	}
+
+	for name, guard := range map[string]*Clause{"nil": nil, "failed": {err: ErrInvalidValue}} {
+		err := SQL(&testUser{ID: 1, Age: 10}).Upsert(ctx, []string{"id"}, []string{"age"}, guard)
+		if !errors.Is(err, ErrInvalidValue) {
+			t.Errorf("Upsert with a %s guard = %v, want ErrInvalidValue", name, err)
+		}
+	}
+
+	openDryRunDB(t, "mysql")
+
+	err = SQL(&testUser{ID: 1, Age: 10}).Upsert(ctx, []string{"id"}, []string{"age"}, newer)
+	if !errors.Is(err, ErrUnsupportedDriver) {
+		t.Fatalf("guarded Upsert on MySQL = %v, want ErrUnsupportedDriver", err)
+	}
+}
+
+func TestByIDsUnique(t *testing.T) {
+	openTestDB(t, &testUser{})
+	insertUsers(t, "ann", "bob", "cid")
+
+	logger := &capturingLogger{}
+	SetLogger(logger)
+
+	users, err := SQL(&testUser{}).ByIDsUnique(context.Background(), []any{1, 3, 1, 3, 1})
+	if err != nil {
+		t.Fatalf("ByIDsUnique: %v", err)
+	}
+
+	if len(users) != 2 || users[0].Name != "ann" || users[1].Name != "cid" {
+		t.Fatalf("ByIDsUnique = %+v, want ann and cid once", users)
+	}
+
+	if len(logger.queries) != 1 || !reflect.DeepEqual(logger.queries[0].args, []any{1, 3}) {
+		t.Fatalf("ran %+v, want a single query for the unique ids", logger.queries)
+	}
+}
+
+func TestSetDefaultLimit(t *testing.T) {
+	openTestDB(t, &testUser{})
+	insertUsers(t, "ann", "bob", "cid")
+	SetDefaultLimit(2)
+
+	ctx := context.Background()
+
+	users, err := SQL(&testUser{}).Find(ctx)
+	if err != nil || len(users) != 2 {
+		t.Fatalf("Find = %d users, %v, want the default limit of 2", len(users), err)
+	}
+
+	users, err = SQL(&testUser{}).NoLimit().Find(ctx)
+	if err != nil || len(users) != 3 {
+		t.Fatalf("NoLimit Find = %d users, %v, want all 3", len(users), err)
+	}
+
+	users, err = SQL(&testUser{}).Limit(1).Find(ctx)
+	if err != nil || len(users) != 1 {
+		t.Fatalf("Limit Find = %d users, %v, want the explicit limit of 1", len(users), err)
+	}
+}
+
+func TestUpdateCaseByID(t *testing.T) {
+	openTestDB(t, &testUser{})
+	insertUsers(t, "ann", "bob", "cid")
+
+	logger := &capturingLogger{}
+	SetLogger(logger)
+
+	ctx := context.Background()
+
+	affected, err := SQL(&testUser{}).UpdateCaseByID(ctx, "age", map[any]any{uint(1): 10, uint(3): 30})
+	if err != nil {
+		t.Fatalf("UpdateCaseByID: %v", err)
+	}
+
+	if affected != 2 || len(logger.queries) != 1 {
+		t.Fatalf("updated %d rows in %d queries, want 2 rows in one", affected, len(logger.queries))
+	}
+
+	users, err := SQL(&testUser{}).OrderBy("id", true).Find(ctx)
+	if err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+
+	if ages := []int{users[0].Age, users[1].Age, users[2].Age}; !reflect.DeepEqual(ages, []int{10, 2, 30}) {
+		t.Fatalf("ages = %v, want [10 2 30]", ages)
+	}
+}
+
+type testAuthor struct {
+	ID    uint
+	Name  string
+	Books []*testBook `gorm:"foreignKey:AuthorID"`
+}
+
+func (*testAuthor) TableName() string { return "authors" }
+
+type testBook struct {
+	ID       uint
+	Title    string
+	AuthorID uint
+	Author   *testAuthor
+}
+
+func (*testBook) TableName() string { return "books" }
+
+// insertLibrary inserts the author ann with the books a and b, and bob with c.
+func insertLibrary(t *testing.T) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	for _, author := range []*testAuthor{{Name: "ann"}, {Name: "bob"}} {
+		if err := SQL(author).Insert(ctx); err != nil {
+			t.Fatalf("insert %s: %v", author.Name, err)
+		}
+	}
+
+	for _, book := range []*testBook{{Title: "a", AuthorID: 1}, {Title: "b", AuthorID: 1}, {Title: "c", AuthorID: 2}} {
+		if err := SQL(book).Insert(ctx); err != nil {
+			t.Fatalf("insert %s: %v", book.Title, err)
+		}
+	}
+}
+
+func TestJoinPreload(t *testing.T) {
+	openTestDB(t, &testAuthor{}, &testBook{})
+	insertLibrary(t)
+
+	logger := &capturingLogger{}
+	SetLogger(logger)
+
+	books, err := SQL(&testBook{}).JoinPreload("Author").Where(EQ("books.title", "c")).Find(context.Background())
+	if err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+
+	if len(books) != 1 || books[0].Author == nil || books[0].Author.Name != "bob" {
+		t.Fatalf("Find = %+v, want c with its author bob", books)
+	}
+
+	if len(logger.queries) != 1 {
+		t.Fatalf("ran %d queries, want the association joined in one", len(logger.queries))
+	}
+
+	for name, cond := range map[string]*Clause{"nil": nil, "failed": {err: ErrInvalidValue}} {
+		if _, err := SQL(&testBook{}).JoinPreload("Author", cond).Find(context.Background()); !errors.Is(err, ErrInvalidValue) {
+			t.Errorf("JoinPreload with a %s condition = %v, want ErrInvalidValue", name, err)
+		}
+	}
+}
+
+func TestRowsAffected(t *testing.T) {
+	openTestDB(t, &testUser{})
+	insertUsers(t, "ann", "bob", "cid")
+
+	tx, err := SQL(&testUser{Age: 9}).Where(GT("id", 1)).UpdateTx(context.Background())
+	if err != nil {
+		t.Fatalf("UpdateTx: %v", err)
+	}
+
+	if tx.RowsAffected() != 2 {
+		t.Fatalf("RowsAffected() = %d, want 2", tx.RowsAffected())
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+}
+
+func TestSelectCoalesce(t *testing.T) {
+	gormdb := openTestDB(t, &testUser{})
+	insertUsers(t, "ann")
+
+	if err := gormdb.Exec("INSERT INTO users (age) VALUES (2)").Error; err != nil {
+		t.Fatalf("insert a NULL name: %v", err)
+	}
+
+	query := SQL(&testUser{}).Select("id").SelectCoalesce("name", "unknown", "name").OrderBy("id", true)
+
+	sql, vars := dryRun(t, query)
+	if !strings.HasPrefix(sql, "SELECT id, COALESCE(`name`, ?) AS `name` FROM") || !reflect.DeepEqual(vars, []any{"unknown"}) {
+		t.Fatalf("SelectCoalesce renders %q %v", sql, vars)
+	}
+
+	users, err := query.Find(context.Background())
+	if err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+
+	if len(users) != 2 || users[0].Name != "ann" || users[1].Name != "unknown" {
+		t.Fatalf("Find = %+v, want ann then the default", users)
+	}
+}
+
+// openReplica makes a migrated SQLite file to serve as a replica of the package
+// db, returning its dialector for RegisterReplicas and a handle to seed it.
+func openReplica(t *testing.T, models ...any) (gorm.Dialector, *gorm.DB) {
+	t.Helper()
+
+	dialector := sqlite.Open(filepath.Join(t.TempDir(), "replica.db"))
+
+	replica, err := gorm.Open(dialector, &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open the replica: %v", err)
+	}
+
+	if err := replica.AutoMigrate(models...); err != nil {
+		t.Fatalf("migrate the replica: %v", err)
+	}
+
+	t.Cleanup(func() {
+		if sqlDB, err := replica.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	return dialector, replica
+}
+
+func TestReadFresh(t *testing.T) {
+	openTestDB(t, &testUser{})
+	dialector, replica := openReplica(t, &testUser{})
+
+	if err := RegisterReplicas(dialector); err != nil {
+		t.Fatalf("RegisterReplicas: %v", err)
+	}
+
+	insertUsers(t, "primary")
+
+	if err := replica.Create(&testUser{Name: "lagging"}).Error; err != nil {
+		t.Fatalf("seed the replica: %v", err)
+	}
+
+	user, err := SQL(&testUser{}).ReadFresh().One(context.Background())
+	if err != nil {
+		t.Fatalf("ReadFresh One: %v", err)
+	}
+
+	if user.Name != "primary" {
+		t.Fatalf("ReadFresh read %q, want the primary row", user.Name)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	openTestDB(t, &testUser{})
+	insertUsers(t, "ann", "bob")
+
+	ctx := context.Background()
+
+	if err := SQL(&testUser{}).Truncate(ctx); err != nil {
+		t.Fatalf("Truncate: %v", err)
+	}
+
+	if count, err := SQL(&testUser{}).Count(ctx); err != nil || count != 0 {
+		t.Fatalf("Count after Truncate = %d, %v, want 0", count, err)
+	}
+
+	if err := SQL(&testUser{}).TruncateCascade(ctx); !errors.Is(err, ErrUnsupportedDriver) {
+		t.Fatalf("TruncateCascade on SQLite = %v, want ErrUnsupportedDriver", err)
+	}
+}
+
+func TestInsertBatchReturning(t *testing.T) {
+	openTestDB(t, &testUser{})
+	insertUsers(t, "existing")
+
+	ctx := context.Background()
+	batch := []*testUser{{Name: "ann"}, {Name: "bob"}, {Name: "cid"}}
+
+	ids, err := SQL(&testUser{}).InsertBatchReturning(ctx, batch)
+	if err != nil {
+		t.Fatalf("InsertBatchReturning: %v", err)
+	}
+
+	if len(ids) != len(batch) {
+		t.Fatalf("returned %d ids for %d rows", len(ids), len(batch))
+	}
+
+	for i, id := range ids {
+		user, err := SQL(&testUser{}).Where(EQ("id", id)).One(ctx)
+		if err != nil {
+			t.Fatalf("One(%v): %v", id, err)
+		}
+
+		if user.Name != batch[i].Name {
+			t.Errorf("id %v is %q, want %q", id, user.Name, batch[i].Name)
+		}
+	}
+}
+
+func TestQueryRowsCtx(t *testing.T) {
+	openTestDB(t, &testUser{})
+	insertUsers(t, "ann", "bob", "cid")
+
+	users, err := SQL(&testUser{}).
+		Where(EQ("name", "ann")).
+		QueryRowsCtx(context.Background(), "SELECT id, upper(name) AS name, age * 10 AS age FROM users WHERE age > ? ORDER BY id", 1)
+	if err != nil {
+		t.Fatalf("QueryRowsCtx: %v", err)
+	}
+
+	want := []*testUser{{ID: 2, Name: "BOB", Age: 20}, {ID: 3, Name: "CID", Age: 30}}
+	if !reflect.DeepEqual(users, want) {
+		t.Fatalf("QueryRowsCtx = %+v, want %+v without the Where scope", users, want)
+	}
+}
+
+func TestLockRequiresTx(t *testing.T) {
+	openTestDB(t, &testUser{})
+	insertUsers(t, "ann")
+
+	ctx := context.Background()
+
+	if _, err := SQL(&testUser{}).ForUpdate().Find(ctx); !errors.Is(err, ErrLockRequiresTx) {
+		t.Fatalf("ForUpdate outside a transaction = %v, want ErrLockRequiresTx", err)
+	}
+
+	err := WithTransaction(ctx, func(tx Transaction) error {
+		_, err := SQL(&testUser{}).SetTx(tx, false).ForUpdate().Find(ctx)
+
+		return err
+	})
+	if err != nil {
+		t.Fatalf("ForUpdate inside a transaction: %v", err)
+	}
+}
+
+func TestDeferConstraints(t *testing.T) {
+	openTestDB(t, &testUser{})
+
+	err := WithTransaction(context.Background(), func(tx Transaction) error {
+		return tx.DeferConstraints()
+	})
+	if !errors.Is(err, ErrUnsupportedDriver) {
+		t.Fatalf("DeferConstraints on SQLite = %v, want ErrUnsupportedDriver", err)
+	}
+}
+
+func TestAs(t *testing.T) {
+	openTestDB(t, &testUser{})
+	insertUsers(t, "ann", "bob", "cid")
+
+	var rows []struct {
+		Name     string
+		HasOlder bool
+	}
+
+	older := SQL(&testUser{}).As("o").Select("o.id").Where(GT("o.age", gorm.Expr("u.age")))
+
+	err := SQL(&testUser{}).
+		As("u").
+		Select("u.name").
+		SelectExists("has_older", older).
+		Where(GT("u.id", 1)).
+		OrderBy("u.id", true).
+		ScanInto(context.Background(), &rows)
+	if err != nil {
+		t.Fatalf("ScanInto: %v", err)
+	}
+
+	if len(rows) != 2 || rows[0].Name != "bob" || !rows[0].HasOlder || rows[1].Name != "cid" || rows[1].HasOlder {
+		t.Fatalf("self-join = %+v, want bob with an older user and cid without", rows)
+	}
+}
+
+func TestHavingAlias(t *testing.T) {
+	for dialect, having := range map[string]string{
+		"mysql":    "HAVING total > ?",
+		"postgres": "HAVING SUM(age) > ?",
+	} {
+		openDryRunDB(t, dialect)
+
+		query := SQL(&testUser{}).SelectExpr("name").SelectExpr("SUM(age) AS total").GroupBy("name").Having(GT("total", 5))
+
+		sql, vars := dryRun(t, query)
+		if !strings.HasSuffix(sql, having) || !reflect.DeepEqual(vars, []any{5}) {
+			t.Errorf("%s: Having renders %q %v, want it ending in %q", dialect, sql, vars, having)
+		}
+	}
+}
+
+type testChange struct {
+	ID        uint
+	Name      string
+	ChangedAt time.Time
+}
+
+func (*testChange) TableName() string { return "changes" }
+
+func TestModifiedSince(t *testing.T) {
+	openTestDB(t, &testChange{})
+
+	ctx := context.Background()
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, change := range []*testChange{
+		{Name: "late", ChangedAt: since.Add(2 * time.Hour)},
+		{Name: "old", ChangedAt: since.Add(-time.Hour)},
+		{Name: "tie", ChangedAt: since.Add(time.Hour)},
+		{Name: "at", ChangedAt: since},
+		{Name: "tie again", ChangedAt: since.Add(time.Hour)},
+	} {
+		if err := SQL(change).Insert(ctx); err != nil {
+			t.Fatalf("insert %s: %v", change.Name, err)
+		}
+	}
+
+	changes, err := SQL(&testChange{}).ModifiedSince(ctx, "changed_at", since)
+	if err != nil {
+		t.Fatalf("ModifiedSince: %v", err)
+	}
+
+	names := make([]string, 0, len(changes))
+	for _, change := range changes {
+		names = append(names, change.Name)
+	}
+
+	if want := []string{"tie", "tie again", "late"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("ModifiedSince = %v, want %v", names, want)
+	}
+}
+
+type testParent struct {
+	ID        uint
+	Name      string
+	Children  []*testChild `gorm:"foreignKey:ParentID"`
+	DeletedAt gorm.DeletedAt
+}
+
+func (*testParent) TableName() string { return "parents" }
+
+type testChild struct {
+	ID        uint
+	ParentID  uint
+	DeletedAt gorm.DeletedAt
+}
+
+func (*testChild) TableName() string { return "children" }
+
+func TestCascadeSoftDelete(t *testing.T) {
+	openTestDB(t, &testParent{}, &testChild{})
+
+	ctx := context.Background()
+
+	for _, parent := range []*testParent{{Name: "deleted"}, {Name: "kept"}} {
+		if err := SQL(parent).Insert(ctx); err != nil {
+			t.Fatalf("insert %s: %v", parent.Name, err)
+		}
+	}
+
+	for _, parentID := range []uint{1, 1, 2} {
+		if err := SQL(&testChild{ParentID: parentID}).Insert(ctx); err != nil {
+			t.Fatalf("insert a child of %d: %v", parentID, err)
+		}
+	}
+
+	if err := SQL(&testParent{ID: 1}).CascadeSoftDelete("Children").Delete(ctx); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	children, err := SQL(&testChild{}).Find(ctx)
+	if err != nil {
+		t.Fatalf("Find children: %v", err)
+	}
+
+	if len(children) != 1 || children[0].ParentID != 2 {
+		t.Fatalf("children left = %+v, want only the kept parent's", children)
+	}
+
+	deleted, err := SQL(&testChild{}).Unscoped().Where(IsNotNull("deleted_at")).Count(ctx)
+	if err != nil || deleted != 2 {
+		t.Fatalf("soft-deleted children = %d, %v, want 2 kept as rows", deleted, err)
+	}
+
+	if err := SQL(&testParent{}).Where(EQ("name", "kept")).CascadeSoftDelete("Children").Delete(ctx); err != nil {
+		t.Fatalf("Delete by Where: %v", err)
+	}
+
+	if count, err := SQL(&testChild{}).Count(ctx); err != nil || count != 0 {
+		t.Fatalf("children left = %d, %v, want those of the parent picked by Where deleted", count, err)
+	}
+
+	err = SQL(&testParent{ID: 2}).CascadeSoftDelete("Unknown").Delete(ctx)
+	if !errors.Is(err, ErrInvalidValue) {
+		t.Fatalf("cascading an unknown association = %v, want ErrInvalidValue", err)
+	}
+}
+
+func TestUpsertBatch(t *testing.T) {
+	openTestDB(t, &testUser{})
+	insertUsers(t, "ann", "bob")
+
+	ctx := context.Background()
+	batch := []*testUser{
+		{ID: 1, Name: "anna"},
+		{ID: 2, Name: "bobby"},
+		{ID: 3, Name: "cid"},
+		{ID: 4, Name: "dan"},
+		{ID: 5, Name: "eve"},
+	}
+
+	logger := &capturingLogger{}
+	SetLogger(logger)
+
+	if err := SQL(&testUser{}).PerChunkTx().UpsertBatch(ctx, batch, []string{"id"}, []string{"name"}, 2); err != nil {
+		t.Fatalf("UpsertBatch: %v", err)
+	}
+
+	if len(logger.queries) != 3 {
+		t.Fatalf("ran %d statements, want one per chunk of 2", len(logger.queries))
+	}
+
+	users, err := SQL(&testUser{}).OrderBy("id", true).Find(ctx)
+	if err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+
+	names := make([]string, 0, len(users))
+	for _, user := range users {
+		names = append(names, user.Name)
+	}
+
+	if want := []string{"anna", "bobby", "cid", "dan", "eve"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("names = %v, want %v", names, want)
+	}
+
+	if users[0].Age != 1 {
+		t.Fatalf("updated row %+v, want its age kept", users[0])
+	}
+}
+
+func TestOnSlowQuery(t *testing.T) {
+	openTestDB(t, &testUser{})
+	insertUsers(t, "ann")
+
+	var slow []string
+	OnSlowQuery(func(sql string, _ time.Duration) {
+		slow = append(slow, sql)
+	})
+	SetSlowQueryThreshold(20 * time.Millisecond)
+
+	ctx := context.Background()
+
+	if _, err := SQL(&testUser{}).Where(EQ("name", "ann")).Find(ctx); err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+
+	if len(slow) != 0 {
+		t.Fatalf("fast Find reported as slow: %v", slow)
+	}
+
+	slowCondition := Raw("(WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < ?) SELECT count(*) FROM n) > 0", 1000000)
+
+	if _, err := SQL(&testUser{}).Where(slowCondition).Find(ctx); err != nil {
+		t.Fatalf("slow Find: %v", err)
+	}
+
+	if len(slow) != 1 || !strings.Contains(slow[0], "i < 1000000") {
+		t.Fatalf("slow queries = %q, want the interpolated count", slow)
+	}
+}
+
+func TestCounts(t *testing.T) {
+	openTestDB(t, &testUser{})
+	insertUsers(t, "ann", "bob", "ann")
+
+	logger := &capturingLogger{}
+	SetLogger(logger)
+
+	counts, err := SQL(&testUser{}).Counts(context.Background(),
+		CountSpec{Label: "all"},
+		CountSpec{Label: "senior", Where: GT("age", 1)},
+		CountSpec{Label: "names", Distinct: "name"},
+	)
+	if err != nil {
+		t.Fatalf("Counts: %v", err)
+	}
+
+	if want := map[string]int64{"all": 3, "senior": 2, "names": 2}; !reflect.DeepEqual(counts, want) {
+		t.Fatalf("Counts = %v, want %v", counts, want)
+	}
+
+	if len(logger.queries) != 1 {
+		t.Fatalf("ran %d statements, want one", len(logger.queries))
+	}
+
+	for where, want := range map[*Clause]error{
+		{err: ErrInvalidValue}:    ErrInvalidValue,
+		RangeOverlap("age", 1, 2): ErrUnsupportedDriver,
+	} {
+		_, err := SQL(&testUser{}).Counts(context.Background(), CountSpec{Label: "bad", Where: where})
+		if !errors.Is(err, want) {
+			t.Errorf("Counts with an invalid spec = %v, want %v", err, want)
+		}
+	}
+}
+
+func TestOrderByCI(t *testing.T) {
+	openTestDB(t, &testUser{})
+	insertUsers(t, "bob", "Ann", "cid")
+
+	query := SQL(&testUser{}).OrderByCI("name", false)
+
+	if sql, _ := dryRun(t, query); !strings.HasSuffix(sql, "ORDER BY LOWER(`name`)") {
+		t.Fatalf("OrderByCI renders %q", sql)
+	}
+
+	users, err := query.Find(context.Background())
+	if err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+
+	if users[0].Name != "Ann" || users[1].Name != "bob" || users[2].Name != "cid" {
+		t.Fatalf("ordered %+v, want Ann, bob, cid", users)
+	}
+}
+
+func TestPaginateGrouped(t *testing.T) {
+	openTestDB(t, &testUser{})
+	insertUsers(t, "ann", "bob", "ann", "cid", "dan")
+
+	items, total, err := SQL(&testUser{}).
+		SelectExpr("name").
+		SelectExpr("SUM(age) AS age").
+		GroupBy("name").
+		OrderBy("name", true).
+		Paginate(context.Background(), 2, 2)
+	if err != nil {
+		t.Fatalf("Paginate: %v", err)
+	}
+
+	want := []*testUser{{Name: "cid", Age: 4}, {Name: "dan", Age: 5}}
+	if total != 4 || !reflect.DeepEqual(items, want) {
+		t.Fatalf("Paginate = %+v of %d, want %+v of 4 groups", items, total, want)
+	}
+}
+
+func TestRows(t *testing.T) {
+	openTestDB(t, &testUser{})
+	insertUsers(t, "ann", "bob")
+
+	rows, err := SQL(&testUser{}).Select("id", "name").OrderBy("id", true).Rows(context.Background())
+	if err != nil {
+		t.Fatalf("Rows: %v", err)
+	}
+	defer rows.Close()
+
+	var names []string
+
+	for rows.Next() {
+		var (
+			id   uint
+			name string
+		)
+
+		if err := rows.Scan(&id, &name); err != nil {
+			t.Fatalf("Scan: %v", err)
+		}
+
+		names = append(names, fmt.Sprintf("%d %s", id, name))
+	}
+
+	if err := rows.Err(); err != nil {
+		t.Fatalf("Err: %v", err)
+	}
+
+	if !reflect.DeepEqual(names, []string{"1 ann", "2 bob"}) {
+		t.Fatalf("scanned %v, want 1 ann and 2 bob", names)
+	}
+}
+
+func TestInsertColumns(t *testing.T) {
+	openTestDB(t, &testUser{})
+
+	logger := &capturingLogger{}
+	SetLogger(logger)
+
+	ctx := context.Background()
+
+	if err := SQL(&testUser{Name: "ann", Age: 7}).InsertColumns("name").Insert(ctx); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+
+	if len(logger.queries) != 1 || !strings.HasPrefix(logger.queries[0].sql, "INSERT INTO `users` (`name`) VALUES") {
+		t.Fatalf("ran %+v, want an INSERT of the name only", logger.queries)
+	}
+
+	user, err := SQL(&testUser{}).One(ctx)
+	if err != nil {
+		t.Fatalf("One: %v", err)
+	}
+
+	if user.Name != "ann" || user.Age != 0 {
+		t.Fatalf("stored %+v, want the age left to its default", user)
+	}
+}
+
+func TestNested(t *testing.T) {
+	openTestDB(t, &testUser{})
+
+	ctx := context.Background()
+	failure := errors.New("nested failure")
+
+	err := WithTransaction(ctx, func(tx Transaction) error {
+		if err := SQL(&testUser{Name: "before"}).SetTx(tx, false).Insert(ctx); err != nil {
+			return err
+		}
+
+		err := tx.Nested(ctx, func(tx Transaction) error {
+			if err := SQL(&testUser{Name: "nested"}).SetTx(tx, false).Insert(ctx); err != nil {
+				return err
+			}
+
+			return failure
+		})
+		if !errors.Is(err, failure) {
+			t.Errorf("Nested = %v, want its block's error", err)
+		}
+
+		return SQL(&testUser{Name: "after"}).SetTx(tx, false).Insert(ctx)
+	})
+	if err != nil {
+		t.Fatalf("WithTransaction: %v", err)
+	}
+
+	users, err := SQL(&testUser{}).OrderBy("id", true).Find(ctx)
+	if err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+
+	if len(users) != 2 || users[0].Name != "before" || users[1].Name != "after" {
+		t.Fatalf("committed %+v, want before and after only", users)
+	}
+}
+
+func TestNestedFailedWrite(t *testing.T) {
+	openTestDB(t, &testUser{})
+
+	ctx := context.Background()
+
+	err := WithTransaction(ctx, func(tx Transaction) error {
+		before := &testUser{Name: "before"}
+		if err := SQL(before).SetTx(tx, false).Insert(ctx); err != nil {
+			return err
+		}
+
+		err := tx.Nested(ctx, func(tx Transaction) error {
+			return SQL(&testUser{ID: before.ID, Name: "duplicate"}).SetTx(tx, false).Insert(ctx)
+		})
+		if err == nil {
+			t.Error("Nested with a duplicate key succeeded")
+		}
+
+		if !tx.IsActive() {
+			t.Error("the failed nested write ended the outer transaction")
+		}
+
+		return SQL(&testUser{Name: "after"}).SetTx(tx, false).Insert(ctx)
+	})
+	if err != nil {
+		t.Fatalf("WithTransaction: %v", err)
+	}
+
+	users, err := SQL(&testUser{}).OrderBy("id", true).Find(ctx)
+	if err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+
+	if len(users) != 2 || users[0].Name != "before" || users[1].Name != "after" {
+		t.Fatalf("committed %+v, want before and after only", users)
+	}
+}
+
+// dryRunTx is a transaction on the DryRun db, letting locks be rendered.
+func dryRunTx() Transaction {
+	return &transaction{tx: db.Session(&gorm.Session{DryRun: true})}
+}
+
+func TestLockOf(t *testing.T) {
+	openDryRunDB(t, "postgres")
+
+	query := SQL(&testBook{}).SetTx(dryRunTx(), false).JoinPreload("Author").LockOf("books")
+
+	if sql, _ := dryRun(t, query); !strings.HasSuffix(sql, "FOR UPDATE OF `books`") {
+		t.Fatalf("LockOf renders %q", sql)
+	}
+}
+
+func TestExistsBy(t *testing.T) {
+	openTestDB(t, &testUser{})
+	insertUsers(t, "ann")
+
+	ctx := context.Background()
+
+	for name, want := range map[string]bool{"ann": true, "bob": false} {
+		exists, err := SQL(&testUser{}).ExistsBy(ctx, "name", name)
+		if err != nil {
+			t.Fatalf("ExistsBy(%s): %v", name, err)
+		}
+
+		if exists != want {
+			t.Errorf("ExistsBy(%s) = %v, want %v", name, exists, want)
+		}
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	openTestDB(t, &testUser{})
+	insertUsers(t, "ann", "bob", "cid")
+
+	sum, err := Aggregate(context.Background(), SQL(&testUser{}).Where(GT("age", 1)), 0, func(sum int, user *testUser) int {
+		return sum + user.Age
+	})
+	if err != nil {
+		t.Fatalf("Aggregate: %v", err)
+	}
+
+	if sum != 5 {
+		t.Fatalf("Aggregate summed %d, want 5", sum)
+	}
+}
+
+// breakingConnector opens SQLite connections to dsn until broken is set, failing
+// with driver.ErrBadConn afterwards as an unreachable replica would.
+type breakingConnector struct {
+	driver driver.Driver
+	dsn    string
+	broken atomic.Bool
+}
+
+func (c *breakingConnector) Connect(context.Context) (driver.Conn, error) {
+	if c.broken.Load() {
+		return nil, driver.ErrBadConn
+	}
+
+	return c.driver.Open(c.dsn)
+}
+
+func (c *breakingConnector) Driver() driver.Driver { return c.driver }
+
+func TestReplicaFallback(t *testing.T) {
+	openTestDB(t, &testUser{})
+	insertUsers(t, "primary")
+
+	sqliteDB, err := sql.Open(sqlite.DriverName, "")
+	if err != nil {
+		t.Fatalf("open the driver: %v", err)
+	}
+	defer sqliteDB.Close()
+
+	connector := &breakingConnector{driver: sqliteDB.Driver(), dsn: filepath.Join(t.TempDir(), "replica.db")}
+	replica := sql.OpenDB(connector)
+	replica.SetMaxIdleConns(0)
+	defer replica.Close()
+
+	if err := RegisterReplicas(sqlite.Dialector{Conn: replica}); err != nil {
+		t.Fatalf("RegisterReplicas: %v", err)
+	}
+
+	connector.broken.Store(true)
+
+	ctx := context.Background()
+
+	if _, err := SQL(&testUser{}).Find(ctx); !errors.Is(err, driver.ErrBadConn) {
+		t.Fatalf("Find on a broken replica = %v, want driver.ErrBadConn", err)
+	}
+
+	SetReplicaFallback(true)
+
+	users, err := SQL(&testUser{}).Find(ctx)
+	if err != nil {
+		t.Fatalf("Find with the fallback: %v", err)
+	}
+
+	if len(users) != 1 || users[0].Name != "primary" {
+		t.Fatalf("Find = %+v, want the primary row", users)
+	}
+}
+
+func TestUpdateFrom(t *testing.T) {
+	openTestDB(t, &testAuthor{}, &testBook{})
+	insertLibrary(t)
+
+	ctx := context.Background()
+	updateFrom := func() (int64, error) {
+		return SQL(&testAuthor{}).UpdateFrom(ctx,
+			SQL(&testBook{}).As("s").Where(EQ("title", "c")),
+			map[string]any{"name": gorm.Expr("s.title")},
+			EQ("authors.id", gorm.Expr("s.author_id")),
+		)
+	}
+
+	affected, err := updateFrom()
+	if err != nil || affected != 1 {
+		t.Fatalf("UpdateFrom = %d, %v, want 1 row", affected, err)
+	}
+
+	authors, err := SQL(&testAuthor{}).OrderBy("id", true).Find(ctx)
+	if err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+
+	if authors[0].Name != "ann" || authors[1].Name != "c" {
+		t.Fatalf("authors = %+v, want bob renamed after his book", authors)
+	}
+
+	_, err = SQL(&testAuthor{}).UpdateFrom(ctx, SQL(&testBook{}), map[string]any{"name": "x"}, nil)
+	if !errors.Is(err, ErrMissingWhereClause) {
+		t.Fatalf("UpdateFrom without on = %v, want ErrMissingWhereClause", err)
+	}
+
+	for dialect, want := range map[string]string{
+		"postgres": "UPDATE `authors` SET `name` = s.title FROM (SELECT * FROM `books` AS s WHERE title = ?) AS s WHERE authors.id = s.author_id [c]",
+		"mysql":    "UPDATE `authors` JOIN (SELECT * FROM `books` AS s WHERE title = ?) AS s ON authors.id = s.author_id SET `authors`.`name` = s.title [c]",
+	} {
+		connector, _ := openRecordingDB(t, dialect)
+
+		if _, err := updateFrom(); err != nil {
+			t.Fatalf("%s: UpdateFrom: %v", dialect, err)
+		}
+
+		if len(connector.execs) != 1 || connector.execs[0] != want {
+			t.Errorf("%s: executed %q, want %q", dialect, connector.execs, want)
+		}
+	}
+}
+
+func TestWhereBoundParam(t *testing.T) {
+	openTestDB(t, &testUser{})
+	insertUsers(t, "ann", "bob")
+
+	ctx := context.Background()
+	search := EQ("name", Param("q")).OR().EQ("CAST(age AS text)", Param("q"))
+
+	users, err := SQL(&testUser{}).Where(search.Bind("q", "2")).Find(ctx)
+	if err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+
+	if len(users) != 1 || users[0].Name != "bob" {
+		t.Fatalf("Find = %+v, want bob", users)
+	}
+
+	if _, err := SQL(&testUser{}).Where(EQ("name", Param("q"))).Find(ctx); !errors.Is(err, ErrInvalidValue) {
+		t.Fatalf("Find with an unbound param = %v, want ErrInvalidValue", err)
+	}
+}
+
+func TestTopNPerGroup(t *testing.T) {
+	openTestDB(t, &testUser{})
+	insertUsers(t, "ann", "bob", "ann", "bob", "ann")
+
+	users, err := SQL(&testUser{}).TopNPerGroup(context.Background(), "name", "age", 2, true)
+	if err != nil {
+		t.Fatalf("TopNPerGroup: %v", err)
+	}
+
+	got := make([]string, 0, len(users))
+	for _, user := range users {
+		got = append(got, fmt.Sprintf("%s %d", user.Name, user.Age))
+	}
+
+	if want := []string{"ann 5", "ann 3", "bob 4", "bob 2"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("TopNPerGroup = %v, want %v", got, want)
+	}
+}
+
+func TestSelectExists(t *testing.T) {
+	openTestDB(t, &testAuthor{}, &testBook{})
+	insertLibrary(t)
+
+	if err := SQL(&testAuthor{Name: "cid"}).Insert(context.Background()); err != nil {
+		t.Fatalf("insert cid: %v", err)
+	}
+
+	var rows []struct {
+		Name     string
+		HasBooks bool
+	}
+
+	err := SQL(&testAuthor{}).
+		Select("name").
+		SelectExists("has_books", SQL(&testBook{}).Select("id").Where(EQ("books.author_id", gorm.Expr("authors.id")))).
+		OrderBy("id", true).
+		ScanInto(context.Background(), &rows)
+	if err != nil {
+		t.Fatalf("ScanInto: %v", err)
+	}
+
+	flags := make([]bool, 0, len(rows))
+	for _, row := range rows {
+		flags = append(flags, row.HasBooks)
+	}
+
+	if !reflect.DeepEqual(flags, []bool{true, true, false}) {
+		t.Fatalf("has_books = %+v, want true for ann and bob only", rows)
+	}
+}
+
+func TestOrderByMany(t *testing.T) {
+	openDryRunDB(t, "postgres")
+
+	for name, query := range map[string]Entitier[*testUser]{
+		"OrderBy":     SQL(&testUser{}).OrderBy("name", true).OrderBy("age", false),
+		"OrderByMany": SQL(&testUser{}).OrderByMany(OrderColumn{Name: "name"}, OrderColumn{Name: "age", Desc: true}),
+	} {
+		if sql, _ := dryRun(t, query); !strings.HasSuffix(sql, "ORDER BY name ASC,age DESC") {
+			t.Errorf("%s renders %q", name, sql)
+		}
+	}
+}
+
+func TestSave(t *testing.T) {
+	openTestDB(t, &testUser{})
+	users := insertUsers(t, "ann", "bob")
+
+	ctx := context.Background()
+	user := users[1]
+	user.Age = 0
+
+	if err := SQL(user).Update(ctx); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	if stored, _ := SQL(&testUser{}).Where(EQ("id", user.ID)).One(ctx); stored.Age != 2 {
+		t.Fatalf("Update wrote the zero age: %+v", stored)
+	}
+
+	if err := SQL(user).Save(ctx); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	stored, err := SQL(&testUser{}).Where(EQ("id", user.ID)).One(ctx)
+	if err != nil {
+		t.Fatalf("One: %v", err)
+	}
+
+	if stored.Age != 0 || stored.Name != "bob" {
+		t.Fatalf("Save stored %+v, want the zero age written", stored)
+	}
+}
+
+func TestSavePoint(t *testing.T) {
+	openTestDB(t, &testUser{})
+
+	ctx := context.Background()
+
+	tx, err := SQL(&testUser{Name: "kept"}).InsertTx(ctx)
+	if err != nil {
+		t.Fatalf("InsertTx: %v", err)
+	}
+
+	if err := tx.SavePoint("sp"); err != nil {
+		t.Fatalf("SavePoint: %v", err)
+	}
+
+	if err := SQL(&testUser{Name: "undone"}).SetTx(tx, false).Insert(ctx); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+
+	if err := tx.RollbackTo("sp"); err != nil {
+		t.Fatalf("RollbackTo: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+
+	users, err := SQL(&testUser{}).Find(ctx)
+	if err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+
+	if len(users) != 1 || users[0].Name != "kept" {
+		t.Fatalf("committed %+v, want only the first insert", users)
+	}
+}
+
+func TestTxFailedWrite(t *testing.T) {
+	openTestDB(t, &testUser{})
+	users := insertUsers(t, "ann")
+
+	tx, err := SQL(&testUser{ID: users[0].ID, Name: "bob"}).InsertTx(context.Background())
+	if !errors.Is(err, ErrDuplicatedKey) || tx != nil {
+		t.Fatalf("InsertTx of a duplicate key = %v, %v, want ErrDuplicatedKey", tx, err)
+	}
+
+	if count, err := SQL(&testUser{}).Count(context.Background()); err != nil || count != 1 {
+		t.Fatalf("Count = %d, %v, want the duplicate rolled back", count, err)
+	}
+}
+
+func TestTxCancelledContext(t *testing.T) {
+	openTestDB(t, &testUser{})
+	users := insertUsers(t, "ann")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	starts := map[string]func(context.Context) (Transaction, error){
+		"InsertTx": SQL(&testUser{Name: "bob"}).InsertTx,
+		"UpdateTx": SQL(&testUser{ID: users[0].ID, Name: "anna"}).UpdateTx,
+		"DeleteTx": SQL(&testUser{ID: users[0].ID}).DeleteTx,
+	}
+
+	for name, start := range starts {
+		if tx, err := start(ctx); !errors.Is(err, context.Canceled) || tx != nil {
+			t.Errorf("%s with a cancelled context = %v, %v, want context.Canceled and no transaction", name, tx, err)
+		}
+	}
+
+	if count, _ := SQL(&testUser{}).Where(EQ("name", "ann")).Count(context.Background()); count != 1 {
+		t.Fatal("a cancelled transaction changed the users")
+	}
+}
+
+func TestReturning(t *testing.T) {
+	openTestDB(t, &testUser{})
+	insertUsers(t, "ann")
+
+	ctx := context.Background()
+
+	user := &testUser{ID: 1, Name: "anna"}
+	if err := SQL(user).Returning("age").Update(ctx); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	if user.Age != 1 {
+		t.Fatalf("Returning read %+v, want the stored age", user)
+	}
+
+	deleted := &testUser{ID: 1}
+	if err := SQL(deleted).Returning("name").Delete(ctx); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	if deleted.Name != "anna" {
+		t.Fatalf("Returning read %+v, want the deleted name", deleted)
+	}
+}
+
+func TestUpdateColumns(t *testing.T) {
+	openTestDB(t, &testUser{})
+	insertUsers(t, "ann", "bob", "cid")
+
+	var affected int64
+	OnWrite(func(_ context.Context, _, _ string, rowsAffected int64) {
+		affected = rowsAffected
+	})
+
+	ctx := context.Background()
+
+	if err := SQL(&testUser{}).Where(LT("age", 3)).UpdateColumns(ctx, map[string]any{"age": 0}); err != nil {
+		t.Fatalf("UpdateColumns: %v", err)
+	}
+
+	if affected != 2 {
+		t.Fatalf("UpdateColumns affected %d rows, want 2", affected)
+	}
+
+	if zeroed, _ := SQL(&testUser{}).Where(EQ("age", 0)).Count(ctx); zeroed != 2 {
+		t.Fatalf("%d rows zeroed, want 2", zeroed)
+	}
+}
+
+func TestUnscoped(t *testing.T) {
+	openTestDB(t, &testParent{})
+
+	ctx := context.Background()
+
+	for _, name := range []string{"deleted", "kept"} {
+		if err := SQL(&testParent{Name: name}).Insert(ctx); err != nil {
+			t.Fatalf("insert %s: %v", name, err)
+		}
+	}
+
+	if err := SQL(&testParent{ID: 1}).Delete(ctx); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	if parents, _ := SQL(&testParent{}).Find(ctx); len(parents) != 1 || parents[0].Name != "kept" {
+		t.Fatalf("Find = %+v, want the soft-deleted row hidden", parents)
+	}
+
+	parent, err := SQL(&testParent{}).Unscoped().Where(EQ("id", 1)).One(ctx)
+	if err != nil {
+		t.Fatalf("Unscoped One: %v", err)
+	}
+
+	if parent.Name != "deleted" || !parent.DeletedAt.Valid {
+		t.Fatalf("Unscoped One = %+v, want the soft-deleted row", parent)
+	}
+}
+
+func TestAfterKeys(t *testing.T) {
+	openTestDB(t, &testUser{})
+	insertUsers(t, "ann", "bob", "cid", "dan")
+
+	ctx := context.Background()
+
+	if err := SQL(&testUser{}).Where(GT("id", 1)).UpdateColumns(ctx, map[string]any{"age": 5}); err != nil {
+		t.Fatalf("tie the ages: %v", err)
+	}
+
+	query := SQL(&testUser{}).AfterKeys([]string{"age", "id"}, []any{5, 2}, false).Limit(2)
+
+	sql, vars := dryRun(t, query)
+	if !strings.HasSuffix(sql, "WHERE (`age`, `id`) > (?, ?) ORDER BY `age`,`id` LIMIT 2") || !reflect.DeepEqual(vars, []any{5, 2}) {
+		t.Fatalf("AfterKeys renders %q %v", sql, vars)
+	}
+
+	users, err := query.Find(ctx)
+	if err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+
+	if len(users) != 2 || users[0].Name != "cid" || users[1].Name != "dan" {
+		t.Fatalf("next page = %+v, want cid and dan after the tied bob", users)
+	}
+
+	users, err = SQL(&testUser{}).AfterKeys([]string{"age", "id"}, []any{5, 3}, true).Find(ctx)
+	if err != nil {
+		t.Fatalf("Find descending: %v", err)
+	}
+
+	if len(users) != 2 || users[0].Name != "bob" || users[1].Name != "ann" {
+		t.Fatalf("descending page = %+v, want bob then ann", users)
+	}
+}
+
+func TestForUpdate(t *testing.T) {
+	for _, dialect := range []string{"postgres", "mysql"} {
+		openDryRunDB(t, dialect)
+
+		tx := dryRunTx()
+
+		if sql, _ := dryRun(t, SQL(&testUser{}).SetTx(tx, false).Where(EQ("id", 1)).ForUpdate()); !strings.HasSuffix(sql, "WHERE id = ? FOR UPDATE") {
+			t.Errorf("%s: ForUpdate renders %q", dialect, sql)
+		}
+
+		if sql, _ := dryRun(t, SQL(&testUser{}).SetTx(tx, false).ForShare()); !strings.HasSuffix(sql, "FOR SHARE") {
+			t.Errorf("%s: ForShare renders %q", dialect, sql)
+		}
+	}
+}
+
+func TestHaving(t *testing.T) {
+	openTestDB(t, &testUser{})
+	insertUsers(t, "ann", "bob", "ann", "cid", "ann", "bob")
+
+	var groups []struct {
+		Name  string
+		Total int
+	}
+
+	err := SQL(&testUser{}).
+		SelectExpr("name").
+		SelectExpr("COUNT(*) AS total").
+		GroupBy("name").
+		Having(GTE("COUNT(*)", 2).AND().LT("SUM(age)", 9)).
+		ScanInto(context.Background(), &groups)
+	if err != nil {
+		t.Fatalf("ScanInto: %v", err)
+	}
+
+	if len(groups) != 1 || groups[0].Name != "bob" || groups[0].Total != 2 {
+		t.Fatalf("groups = %+v, want bob alone", groups)
+	}
+}
+
+func TestLast(t *testing.T) {
+	openTestDB(t, &testUser{})
+	insertUsers(t, "ann", "bob", "cid")
+
+	user, err := SQL(&testUser{}).Last(context.Background())
+	if err != nil {
+		t.Fatalf("Last: %v", err)
+	}
+
+	if user.ID != 3 || user.Name != "cid" {
+		t.Fatalf("Last = %+v, want the highest primary key", user)
+	}
+}
+
+func TestFindInBatches(t *testing.T) {
+	openTestDB(t, &testUser{})
+
+	ctx := context.Background()
+	users := make([]*testUser, 0, 300)
+
+	for i := 1; i <= 300; i++ {
+		users = append(users, &testUser{Name: fmt.Sprint("user", i), Age: i})
+	}
+
+	if err := SQL(&testUser{}).InsertBatch(ctx, users); err != nil {
+		t.Fatalf("InsertBatch: %v", err)
+	}
+
+	var (
+		sizes []int
+		next  = 51
+	)
+
+	err := SQL(&testUser{}).Where(GT("age", 50)).FindInBatches(ctx, 100, func(batch []*testUser) error {
+		sizes = append(sizes, len(batch))
+
+		for _, user := range batch {
+			if user.Age != next {
+				return fmt.Errorf("got age %d, want %d", user.Age, next)
+			}
+
+			next++
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("FindInBatches: %v", err)
+	}
+
+	if !reflect.DeepEqual(sizes, []int{100, 100, 50}) {
+		t.Fatalf("batch sizes = %v, want 100, 100 and 50 for the 250 matching rows", sizes)
+	}
+
+	if err := SQL(&testUser{}).FindInBatches(ctx, 0, nil); !errors.Is(err, ErrInvalidValue) {
+		t.Fatalf("FindInBatches with no batch size = %v, want ErrInvalidValue", err)
+	}
+}
+
+func TestPreload(t *testing.T) {
+	openTestDB(t, &testAuthor{}, &testBook{})
+	insertLibrary(t)
+
+	authors, err := SQL(&testAuthor{}).Preload("Books", NOT().EQ("title", "b")).OrderBy("id", true).Find(context.Background())
+	if err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+
+	titles := make([]string, 0, 2)
+
+	for _, author := range authors {
+		for _, book := range author.Books {
+			titles = append(titles, author.Name+" "+book.Title)
+		}
+	}
+
+	if !reflect.DeepEqual(titles, []string{"ann a", "bob c"}) {
+		t.Fatalf("preloaded %v, want the books other than b", titles)
+	}
+}
+
+func TestBetweenDates(t *testing.T) {
+	openTestDB(t, &testChange{})
+
+	ctx := context.Background()
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 12, 0, 0, 0, time.UTC) }
+
+	for d := 1; d <= 5; d++ {
+		if err := SQL(&testChange{Name: fmt.Sprint("day", d), ChangedAt: day(d)}).Insert(ctx); err != nil {
+			t.Fatalf("insert day %d: %v", d, err)
+		}
+	}
+
+	changes, err := SQL(&testChange{}).Where(Between("changed_at", day(2), day(4))).OrderBy("id", true).Find(ctx)
+	if err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+
+	if len(changes) != 3 || changes[0].Name != "day2" || changes[2].Name != "day4" {
+		t.Fatalf("Between = %+v, want days 2 to 4 with both bounds", changes)
+	}
+}
+
+func TestSelect(t *testing.T) {
+	openTestDB(t, &testUser{})
+	insertUsers(t, "ann")
+
+	ctx := context.Background()
+
+	for _, cols := range [][]string{{"id", "name"}, {"users.name AS name", "age years"}, {"*"}, {"users.*"}} {
+		if _, err := SQL(&testUser{}).Select(cols...).Find(ctx); err != nil {
+			t.Errorf("Select(%q): %v", cols, err)
+		}
+	}
+
+	for _, col := range []string{"name; DROP TABLE users", "name FROM users --", "(SELECT 1)", "count(*)", ""} {
+		if _, err := SQL(&testUser{}).Select(col).Find(ctx); !errors.Is(err, ErrInvalidField) {
+			t.Errorf("Select(%q) = %v, want ErrInvalidField", col, err)
+		}
+	}
+
+	if count, err := SQL(&testUser{}).Count(ctx); err != nil || count != 1 {
+		t.Fatalf("Count = %d, %v, want the table intact", count, err)
+	}
+}
+
+func TestWhereBuildError(t *testing.T) {
+	openTestDB(t, &testUser{})
+
+	ctx := context.Background()
+
+	if _, err := SQL(&testUser{}).Where(nil).Find(ctx); !errors.Is(err, ErrInvalidValue) {
+		t.Fatalf("Where(nil) = %v, want ErrInvalidValue", err)
+	}
+
+	if _, err := SQL(&testUser{}).Where(EQ("id", 1)).OrWhere(nil).Count(ctx); !errors.Is(err, ErrInvalidValue) {
+		t.Fatalf("OrWhere(nil) = %v, want ErrInvalidValue", err)
+	}
+
+	if _, err := SQL(&testUser{}).Where(TupleIn([]string{"id", "name"}, nil)).Find(ctx); !errors.Is(err, ErrEmptySlice) {
+		t.Fatalf("Where with an empty TupleIn = %v, want ErrEmptySlice", err)
+	}
+
+	failed := func() Entitier[*testUser] { return SQL(&testUser{}).Where(nil) }
+
+	if _, err := SQL(&testUser{}).SelectExists("flag", failed()).Find(ctx); !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("SelectExists of a failed query = %v, want ErrInvalidValue", err)
+	}
+
+	_, err := SQL(&testUser{}).UpdateFrom(ctx, failed(), map[string]any{"age": 1}, EQ("users.id", gorm.Expr("s.id")))
+	if !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("UpdateFrom of a failed query = %v, want ErrInvalidValue", err)
+	}
+
+	if err := failed().Query("SELECT * FROM users"); !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("Query = %v, want ErrInvalidValue", err)
+	}
+
+	if _, err := failed().QueryRows("SELECT * FROM users"); !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("QueryRows = %v, want ErrInvalidValue", err)
+	}
+
+	if err := failed().Exec("DELETE FROM users"); !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("Exec = %v, want ErrInvalidValue", err)
+	}
+}
+
+func TestWhereEmptyClause(t *testing.T) {
+	openTestDB(t, &testUser{})
+	insertUsers(t, "ann", "bob")
+
+	users, err := SQL(&testUser{}).Where(&Clause{}).Find(context.Background())
+	if err != nil {
+		t.Fatalf("Find with an empty clause: %v", err)
+	}
+
+	if len(users) != 2 {
+		t.Fatalf("Find = %+v, want every user", users)
+	}
+}
+
+func TestWhereIf(t *testing.T) {
+	openDryRunDB(t, "postgres")
+
+	plain, plainVars := dryRun(t, SQL(&testUser{}).Where(EQ("age", 1)))
+	skipped, skippedVars := dryRun(t, SQL(&testUser{}).Where(EQ("age", 1)).WhereIf(false, EQ("name", "ann")))
+
+	if skipped != plain || !reflect.DeepEqual(skippedVars, plainVars) {
+		t.Fatalf("WhereIf(false) renders %q %v, want %q %v", skipped, skippedVars, plain, plainVars)
+	}
+
+	applied, appliedVars := dryRun(t, SQL(&testUser{}).Where(EQ("age", 1)).WhereIf(true, EQ("name", "ann")))
+	if !strings.HasSuffix(applied, "WHERE age = ? AND name = ?") || !reflect.DeepEqual(appliedVars, []any{1, "ann"}) {
+		t.Fatalf("WhereIf(true) renders %q %v", applied, appliedVars)
+	}
+}
+
+func TestClone(t *testing.T) {
+	openTestDB(t, &testUser{})
+	insertUsers(t, "ann", "bob", "cid")
+
+	ctx := context.Background()
+	base := SQL(&testUser{}).Where(GT("age", 1))
+
+	young := base.Clone().Where(LT("age", 3))
+	named := base.Clone().Where(EQ("name", "cid"))
+
+	for name, tt := range map[string]struct {
+		query Entitier[*testUser]
+		count int64
+	}{
+		"base":  {base, 2},
+		"young": {young, 1},
+		"named": {named, 1},
+	} {
+		if count, err := tt.query.Count(ctx); err != nil || count != tt.count {
+			t.Errorf("%s Count = %d, %v, want %d", name, count, err, tt.count)
+		}
+	}
+
+	users, err := young.Find(ctx)
+	if err != nil || len(users) != 1 || users[0].Name != "bob" {
+		t.Fatalf("young Find = %+v, %v, want bob", users, err)
+	}
+}
+
+func TestRegisterReplicas(t *testing.T) {
+	_, primary := openReplica(t, &testUser{})
+	Init(primary)
+	t.Cleanup(resetGlobals)
+
+	dialector, replica := openReplica(t, &testUser{})
+
+	if err := RegisterReplicas(dialector); err != nil {
+		t.Fatalf("RegisterReplicas: %v", err)
+	}
+
+	if err := replica.Create(&testUser{Name: "replica"}).Error; err != nil {
+		t.Fatalf("seed the replica: %v", err)
+	}
+
+	ctx := context.Background()
+
+	if err := SQL(&testUser{Name: "primary"}).Insert(ctx); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+
+	users, err := SQL(&testUser{}).Find(ctx)
+	if err != nil || len(users) != 1 || users[0].Name != "replica" {
+		t.Fatalf("Find = %v, %v, want the replica row", users, err)
+	}
+
+	var names []string
+	if err := primary.Clauses(dbresolver.Write).Model(&testUser{}).Pluck("name", &names).Error; err != nil {
+		t.Fatalf("read the primary: %v", err)
+	}
+
+	if !reflect.DeepEqual(names, []string{"primary"}) {
+		t.Fatalf("primary holds %q, want the inserted row only", names)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	openTestDB(t, &testUser{})
+	insertUsers(t, "ann", "bob", "cid", "dan", "eve", "fay", "gus", "hal", "ivy", "jon")
+
+	// The subquery reads the row, so that SQLite evaluates it once per user and
+	// the deadline can stop the scan between two of them.
+	slowCondition := Raw("(WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < ? + users.id) SELECT count(*) FROM n) > 0", 1000000)
+
+	ctx := context.Background()
+
+	_, err := SQL(&testUser{}).Timeout(50 * time.Millisecond).Where(slowCondition).Find(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("slow Find = %v, want context.DeadlineExceeded", err)
+	}
+
+	if users, err := SQL(&testUser{}).Timeout(time.Second).Find(ctx); err != nil || len(users) != 10 {
+		t.Fatalf("fast Find = %d users, %v, want 10", len(users), err)
+	}
+
+	var hookErrs []error
+	OnWrite(func(ctx context.Context, _, _ string, _ int64) {
+		hookErrs = append(hookErrs, ctx.Err())
+	})
+
+	err = WithTransaction(ctx, func(tx Transaction) error {
+		return SQL(&testUser{Name: "kai"}).SetTx(tx, false).Timeout(time.Second).Insert(ctx)
+	})
+	if err != nil {
+		t.Fatalf("WithTransaction: %v", err)
+	}
+
+	if !reflect.DeepEqual(hookErrs, []error{nil}) {
+		t.Fatalf("OnWrite hook context errors = %v, want a live context", hookErrs)
+	}
+}
+
+func TestExplainSQL(t *testing.T) {
+	openTestDB(t, &testUser{})
+
+	sql := SQL(&testUser{}).Where(EQ("name", "ann").AND().GT("age", 30)).ExplainSQL(context.Background())
+	if !strings.HasSuffix(sql, "WHERE name = \"ann\" AND age > 30") {
+		t.Fatalf("ExplainSQL = %q, want the interpolated WHERE", sql)
+	}
+
+	closed = true
+
+	if sql := SQL(&testUser{}).ExplainSQL(context.Background()); sql != "" {
+		t.Fatalf("ExplainSQL on a closed db = %q, want empty", sql)
+	}
+}
@@ -0,0 +1,115 @@
+package entigorm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidFilter JSON filter is malformed or uses an unknown operator or column.
+var ErrInvalidFilter = errors.New("invalid filter")
+
+// filterNode is either a group, holding And or Or, or a single condition.
+type filterNode struct {
+	And   []filterNode `json:"and"`
+	Or    []filterNode `json:"or"`
+	Field string       `json:"field"`
+	Op    string       `json:"op"`
+	Value any          `json:"value"`
+}
+
+var filterOperators = map[string]func(field string, value any) *Clause{
+	"eq":  EQ,
+	"gt":  GT,
+	"gte": GTE,
+	"lt":  LT,
+	"lte": LTE,
+	"in":  IN,
+	"like": func(field string, value any) *Clause {
+		s, _ := value.(string)
+
+		return Like(field, s)
+	},
+}
+
+// WhereJSON applies a filter tree sent as JSON, for example
+// {"and":[{"field":"age","op":"gt","value":18},{"or":[...]}]}. Fields must be
+// columns of the entity and op one of eq, gt, gte, lt, lte, in or like.
+func (e *Entity[E]) WhereJSON(raw []byte) (Entitier[E], error) {
+	var node filterNode
+	if err := json.Unmarshal(raw, &node); err != nil {
+		return e, fmt.Errorf("%w: %w", ErrInvalidFilter, err)
+	}
+
+	if err := ready(); err != nil {
+		return e, e.joinError(err)
+	}
+
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(e.table); err != nil {
+		return e, e.joinError(err)
+	}
+
+	columns := make(map[string]bool, len(stmt.Schema.DBNames))
+	for _, name := range stmt.Schema.DBNames {
+		columns[name] = true
+	}
+
+	c, err := node.clause(columns)
+	if err != nil {
+		return e, err
+	}
+
+	return e.Where(c), nil
+}
+
+func (n filterNode) clause(columns map[string]bool) (*Clause, error) {
+	switch {
+	case n.And != nil || n.Or != nil:
+		if n.And != nil && n.Or != nil {
+			return nil, fmt.Errorf("%w: node has both and and or", ErrInvalidFilter)
+		}
+
+		children := n.And
+		combinator := AllOf
+
+		if n.Or != nil {
+			children = n.Or
+			combinator = AnyOf
+		}
+
+		clauses := make([]*Clause, 0, len(children))
+
+		for _, child := range children {
+			c, err := child.clause(columns)
+			if err != nil {
+				return nil, err
+			}
+
+			clauses = append(clauses, c)
+		}
+
+		return combinator(clauses...), nil
+	default:
+		if !columns[n.Field] {
+			return nil, fmt.Errorf("%w: unknown field %q", ErrInvalidFilter, n.Field)
+		}
+
+		build, ok := filterOperators[n.Op]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown operator %q", ErrInvalidFilter, n.Op)
+		}
+
+		if _, isString := n.Value.(string); n.Op == "like" && !isString {
+			return nil, fmt.Errorf("%w: like requires a string value", ErrInvalidFilter)
+		}
+
+		if _, isSlice := n.Value.([]any); n.Op == "in" && !isSlice {
+			return nil, fmt.Errorf("%w: in requires an array value", ErrInvalidFilter)
+		}
+
+		return build(n.Field, n.Value), nil
+	}
+}
@@ -0,0 +1,41 @@
+package entigorm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWhereJSON(t *testing.T) {
+	openTestDB(t, &testUser{})
+	insertUsers(t, "ann", "bob", "cid", "dan")
+
+	query, err := SQL(&testUser{}).WhereJSON([]byte(`{"and": [
+		{"field": "age", "op": "gt", "value": 1},
+		{"or": [{"field": "name", "op": "eq", "value": "bob"}, {"field": "name", "op": "in", "value": ["dan"]}]}
+	]}`))
+	if err != nil {
+		t.Fatalf("WhereJSON: %v", err)
+	}
+
+	sql, vars := dryRun(t, query)
+	if !strings.HasSuffix(sql, "WHERE (age > ?) AND ((name = ?) OR (name IN (?)))") || !reflect.DeepEqual(vars, []any{1.0, "bob", "dan"}) {
+		t.Fatalf("WhereJSON renders %q %v", sql, vars)
+	}
+
+	users, err := query.OrderBy("id", true).Find(context.Background())
+	if err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+
+	if len(users) != 2 || users[0].Name != "bob" || users[1].Name != "dan" {
+		t.Fatalf("Find = %+v, want bob and dan", users)
+	}
+
+	_, err = SQL(&testUser{}).WhereJSON([]byte(`{"or": [{"field": "password", "op": "eq", "value": "x"}]}`))
+	if !errors.Is(err, ErrInvalidFilter) {
+		t.Fatalf("WhereJSON on an unknown field = %v, want ErrInvalidFilter", err)
+	}
+}
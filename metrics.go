@@ -0,0 +1,52 @@
+package entigorm
+
+import (
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var metrics *queryMetrics
+
+type queryMetrics struct {
+	queries  *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+// RegisterMetrics records every statement run through the package db with
+// registerer: entigorm_queries_total counts them by operation, table and
+// outcome, entigorm_query_duration_seconds observes their latency by operation
+// and table. Operation is find, insert, update, delete or raw.
+func RegisterMetrics(registerer prometheus.Registerer) error {
+	m := &queryMetrics{
+		queries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "entigorm_queries_total",
+			Help: "Statements run, by operation, table and outcome.",
+		}, []string{"operation", "table", "outcome"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "entigorm_query_duration_seconds",
+			Help:    "Statement latency, by operation and table.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"operation", "table"}),
+	}
+
+	if err := errors.Join(registerer.Register(m.queries), registerer.Register(m.duration)); err != nil {
+		return err
+	}
+
+	metrics = m
+
+	return nil
+}
+
+// observe records one statement, a missing record not counting as an error.
+func (m *queryMetrics) observe(op, table string, elapsed time.Duration, err error) {
+	outcome := "success"
+	if err != nil && !errors.Is(err, ErrRecordNotFound) {
+		outcome = "error"
+	}
+
+	m.queries.WithLabelValues(op, table, outcome).Inc()
+	m.duration.WithLabelValues(op, table).Observe(elapsed.Seconds())
+}
@@ -0,0 +1,63 @@
+package entigorm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// queryCount returns the entigorm_queries_total sample of registry matching labels.
+func queryCount(t *testing.T, registry *prometheus.Registry, labels map[string]string) float64 {
+	t.Helper()
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("gather: %v", err)
+	}
+
+	for _, family := range families {
+		if family.GetName() != "entigorm_queries_total" {
+			continue
+		}
+
+	metrics:
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if labels[label.GetName()] != label.GetValue() {
+					continue metrics
+				}
+			}
+
+			return metric.GetCounter().GetValue()
+		}
+	}
+
+	return 0
+}
+
+func TestRegisterMetrics(t *testing.T) {
+	openTestDB(t, &testUser{})
+	insertUsers(t, "ann")
+
+	registry := prometheus.NewRegistry()
+	if err := RegisterMetrics(registry); err != nil {
+		t.Fatalf("RegisterMetrics: %v", err)
+	}
+
+	find := map[string]string{"operation": "find", "table": "users", "outcome": "success"}
+
+	for want := 1.0; want <= 2; want++ {
+		if _, err := SQL(&testUser{}).Find(context.Background()); err != nil {
+			t.Fatalf("Find: %v", err)
+		}
+
+		if got := queryCount(t, registry, find); got != want {
+			t.Fatalf("find counter = %v, want %v", got, want)
+		}
+	}
+
+	if err := RegisterMetrics(registry); err == nil {
+		t.Fatal("registering the metrics twice succeeded")
+	}
+}
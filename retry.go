@@ -0,0 +1,87 @@
+package entigorm
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+const retryBackoff = 10 * time.Millisecond
+
+var (
+	retryClassifier    = IsRetryable
+	retryableSQLStates = map[string]bool{
+		"40001": true, // serialization_failure
+		"40P01": true, // deadlock_detected
+	}
+	retryableMySQLErrors = map[uint16]bool{
+		1205: true, // ER_LOCK_WAIT_TIMEOUT
+		1213: true, // ER_LOCK_DEADLOCK
+	}
+)
+
+// WithRetry runs fn up to attempts times while it fails with a retryable error,
+// waiting 10ms before the second attempt and doubling the wait after each one.
+// It returns the last error, or the context error when ctx ends first. fn is
+// typically a WithTransaction call, so that each attempt starts a fresh one.
+func WithRetry(ctx context.Context, attempts int, fn func() error) error {
+	backoff := retryBackoff
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= attempts || !retryClassifier(err) {
+			return err
+		}
+
+		timer := time.NewTimer(backoff)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return errors.Join(err, ctx.Err())
+		case <-timer.C:
+		}
+
+		backoff *= 2
+	}
+}
+
+// IsRetryable reports whether err carries one of the SQLSTATE codes set by
+// SetRetryableSQLStates, by default serialization failures and deadlocks.
+// The code is read from driver errors having a SQLState() string method.
+// MySQL driver errors are matched on their number instead, deadlocks and lock
+// wait timeouts being retryable.
+func IsRetryable(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return retryableMySQLErrors[mysqlErr.Number]
+	}
+
+	var stateErr interface{ SQLState() string }
+	if !errors.As(err, &stateErr) {
+		return false
+	}
+
+	return retryableSQLStates[stateErr.SQLState()]
+}
+
+// SetRetryableSQLStates replaces the SQLSTATE codes IsRetryable accepts.
+func SetRetryableSQLStates(codes ...string) {
+	retryableSQLStates = make(map[string]bool, len(codes))
+	for _, code := range codes {
+		retryableSQLStates[code] = true
+	}
+}
+
+// SetRetryClassifier makes WithRetry retry the errors fn accepts instead of
+// using IsRetryable, for drivers not exposing SQLSTATE. A nil fn restores it.
+func SetRetryClassifier(fn func(err error) bool) {
+	if fn == nil {
+		fn = IsRetryable
+	}
+
+	retryClassifier = fn
+}
@@ -0,0 +1,65 @@
+package entigorm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// stateError is a driver error reporting a SQLSTATE code.
+type stateError string
+
+func (e stateError) Error() string    { return "sqlstate " + string(e) }
+func (e stateError) SQLState() string { return string(e) }
+
+func TestWithRetry(t *testing.T) {
+	calls := 0
+
+	err := WithRetry(context.Background(), 3, func() error {
+		calls++
+		if calls < 3 {
+			return fmt.Errorf("commit: %w", stateError("40001"))
+		}
+
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Fatalf("WithRetry = %v after %d calls, want success on the third", err, calls)
+	}
+
+	calls = 0
+	failure := stateError("23505")
+
+	err = WithRetry(context.Background(), 3, func() error {
+		calls++
+
+		return failure
+	})
+	if !errors.Is(err, failure) || calls != 1 {
+		t.Fatalf("WithRetry = %v after %d calls, want a single call", err, calls)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{stateError("40001"), true},
+		{stateError("40P01"), true},
+		{stateError("23505"), false},
+		{&mysql.MySQLError{Number: 1213}, true},
+		{&mysql.MySQLError{Number: 1205}, true},
+		{&mysql.MySQLError{Number: 1062}, false},
+		{errors.New("connection refused"), false},
+	}
+
+	for _, test := range tests {
+		if got := IsRetryable(fmt.Errorf("query: %w", test.err)); got != test.want {
+			t.Errorf("IsRetryable(%v) = %v, want %v", test.err, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package entigorm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// ErrSchemaMismatch table columns do not match the entity fields.
+var ErrSchemaMismatch = errors.New("schema mismatch")
+
+// VerifySchema compares the fields of each entity with the columns of its table
+// and reports every missing or extra column. It never alters the database.
+func VerifySchema(ctx context.Context, entities ...entity) error {
+	if err := ready(); err != nil {
+		return err
+	}
+
+	var errs []error
+
+	for _, ent := range entities {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(ent); err != nil {
+			return err
+		}
+
+		columnTypes, err := db.WithContext(ctx).Migrator().ColumnTypes(ent)
+		if err != nil {
+			return err
+		}
+
+		columns := make(map[string]bool, len(columnTypes))
+		for _, ct := range columnTypes {
+			columns[ct.Name()] = true
+		}
+
+		var missing, extra []string
+
+		for _, name := range stmt.Schema.DBNames {
+			if !columns[name] {
+				missing = append(missing, name)
+			}
+
+			delete(columns, name)
+		}
+
+		for name := range columns {
+			extra = append(extra, name)
+		}
+
+		sort.Strings(extra)
+
+		if len(missing) > 0 {
+			errs = append(errs, fmt.Errorf("%w: table %s is missing columns %s",
+				ErrSchemaMismatch, stmt.Schema.Table, strings.Join(missing, ", ")))
+		}
+
+		if len(extra) > 0 {
+			errs = append(errs, fmt.Errorf("%w: table %s has extra columns %s",
+				ErrSchemaMismatch, stmt.Schema.Table, strings.Join(extra, ", ")))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Migrate runs gorm's AutoMigrate for entities, which may also alter the type,
+// size or constraints of existing columns. See SafeMigrate to avoid that.
+func Migrate(ctx context.Context, entities ...entity) error {
+	if err := ready(); err != nil {
+		return err
+	}
+
+	models := make([]any, 0, len(entities))
+	for _, ent := range entities {
+		models = append(models, ent)
+	}
+
+	return db.WithContext(ctx).AutoMigrate(models...)
+}
+
+// SafeMigrate only creates missing tables and adds missing columns, leaving
+// every existing column untouched.
+func SafeMigrate(ctx context.Context, entities ...entity) error {
+	if err := ready(); err != nil {
+		return err
+	}
+
+	migrator := db.WithContext(ctx).Migrator()
+
+	for _, ent := range entities {
+		if !migrator.HasTable(ent) {
+			if err := migrator.CreateTable(ent); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(ent); err != nil {
+			return err
+		}
+
+		for _, name := range stmt.Schema.DBNames {
+			if migrator.HasColumn(ent, name) {
+				continue
+			}
+
+			if err := migrator.AddColumn(ent, name); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
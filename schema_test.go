@@ -0,0 +1,53 @@
+package entigorm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestVerifySchema(t *testing.T) {
+	gormdb := openTestDB(t)
+
+	if err := gormdb.Exec("CREATE TABLE users (id integer PRIMARY KEY, name text, nickname text)").Error; err != nil {
+		t.Fatalf("create the table: %v", err)
+	}
+
+	err := VerifySchema(context.Background(), &testUser{})
+	if !errors.Is(err, ErrSchemaMismatch) {
+		t.Fatalf("VerifySchema = %v, want ErrSchemaMismatch", err)
+	}
+
+	for _, want := range []string{"table users is missing columns age", "table users has extra columns nickname"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("VerifySchema = %q, want it to report %q", err, want)
+		}
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	gormdb := openTestDB(t)
+
+	ctx := context.Background()
+
+	if err := Migrate(ctx, &testUser{}); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+
+	if !gormdb.Migrator().HasTable(&testUser{}) {
+		t.Fatal("Migrate did not create the users table")
+	}
+
+	if err := gormdb.Exec("CREATE TABLE changes (id integer PRIMARY KEY, name text)").Error; err != nil {
+		t.Fatalf("create the changes table: %v", err)
+	}
+
+	if err := SafeMigrate(ctx, &testChange{}, &testParent{}); err != nil {
+		t.Fatalf("SafeMigrate: %v", err)
+	}
+
+	if !gormdb.Migrator().HasColumn(&testChange{}, "changed_at") || !gormdb.Migrator().HasTable(&testParent{}) {
+		t.Fatal("SafeMigrate did not add the missing column and table")
+	}
+}